@@ -0,0 +1,248 @@
+// Package ast defines the syntax tree of a Lox program.
+package ast
+
+import "github.com/kriyanshii/interpreter-go/token"
+
+// Expr is a node in the expression syntax tree. Each concrete node calls
+// the matching method on the visitor it is given.
+type Expr interface {
+	Accept(visitor ExprVisitor) (any, error)
+}
+
+// ExprVisitor is implemented by every pass that walks expressions.
+type ExprVisitor interface {
+	VisitAssignExpr(expr *AssignExpr) (any, error)
+	VisitBinaryExpr(expr *BinaryExpr) (any, error)
+	VisitCallExpr(expr *CallExpr) (any, error)
+	VisitConditionalExpr(expr *ConditionalExpr) (any, error)
+	VisitFunctionExpr(expr *FunctionExpr) (any, error)
+	VisitGetExpr(expr *GetExpr) (any, error)
+	VisitGroupingExpr(expr *GroupingExpr) (any, error)
+	VisitIncrementExpr(expr *IncrementExpr) (any, error)
+	VisitInterpolationExpr(expr *InterpolationExpr) (any, error)
+	VisitLiteralExpr(expr *LiteralExpr) (any, error)
+	VisitLogicalExpr(expr *LogicalExpr) (any, error)
+	VisitSetExpr(expr *SetExpr) (any, error)
+	VisitSuperExpr(expr *SuperExpr) (any, error)
+	VisitThisExpr(expr *ThisExpr) (any, error)
+	VisitUnaryExpr(expr *UnaryExpr) (any, error)
+	VisitVariableExpr(expr *VariableExpr) (any, error)
+}
+
+// BinaryExpr is an infix operation such as "a + b" or "a == b".
+type BinaryExpr struct {
+	Left     Expr
+	Operator token.Token
+	Right    Expr
+}
+
+func (e *BinaryExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitBinaryExpr(e)
+}
+
+// CallExpr calls Callee with Arguments. Paren is the closing parenthesis,
+// used to locate errors raised by the call.
+type CallExpr struct {
+	Callee    Expr
+	Paren     token.Token
+	Arguments []Expr
+}
+
+func (e *CallExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitCallExpr(e)
+}
+
+// ConditionalExpr is "condition ? thenBranch : elseBranch", which evaluates
+// only the branch that condition picks. Question is the "?", used to
+// locate the expression.
+type ConditionalExpr struct {
+	Condition  Expr
+	Question   token.Token
+	ThenBranch Expr
+	ElseBranch Expr
+}
+
+func (e *ConditionalExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitConditionalExpr(e)
+}
+
+// FunctionExpr is an anonymous function, "fun (a, b) { ... }", whose value
+// is the function itself. Having no name, Function is named by its "fun"
+// keyword, which also locates it. The parser also desugars an arrow
+// function, "(a, b) => a + b", into one named by its "=>" whose body
+// returns the expression.
+type FunctionExpr struct {
+	Function *FunctionStmt
+}
+
+func (e *FunctionExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitFunctionExpr(e)
+}
+
+// GetExpr reads a property of an instance: "object.name".
+type GetExpr struct {
+	Object Expr
+	Name   token.Token
+}
+
+func (e *GetExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitGetExpr(e)
+}
+
+// GroupingExpr is a parenthesized expression.
+type GroupingExpr struct {
+	Expression Expr
+}
+
+func (e *GroupingExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitGroupingExpr(e)
+}
+
+// IncrementExpr adds one to a variable or field with "++", or subtracts one
+// with "--". Written before Target, as in "++n", it evaluates to the new
+// value; written after, as in "n++", to the old one.
+type IncrementExpr struct {
+	Operator token.Token
+	Target   Expr
+	Prefix   bool
+}
+
+func (e *IncrementExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitIncrementExpr(e)
+}
+
+// InterpolationExpr is a string literal with embedded expressions, such as
+// "${n} items". Parts holds the literal pieces and the expressions in
+// order; their values are converted to strings and joined. Start is the
+// token opening the string.
+type InterpolationExpr struct {
+	Start token.Token
+	Parts []Expr
+}
+
+func (e *InterpolationExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitInterpolationExpr(e)
+}
+
+// LiteralExpr is a number, string, boolean or nil written in the source.
+// Literals keep no token, so File and Line record where the value was
+// written, as a token's would.
+type LiteralExpr struct {
+	Value any
+	File  string
+	Line  int
+}
+
+func (e *LiteralExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitLiteralExpr(e)
+}
+
+// SetExpr writes a field of an instance: "object.name = value".
+type SetExpr struct {
+	Object Expr
+	Name   token.Token
+	Value  Expr
+}
+
+func (e *SetExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitSetExpr(e)
+}
+
+// SuperExpr looks up Method on the superclass of the enclosing class.
+type SuperExpr struct {
+	Keyword token.Token
+	Method  token.Token
+}
+
+func (e *SuperExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitSuperExpr(e)
+}
+
+// ThisExpr refers to the instance a method was called on.
+type ThisExpr struct {
+	Keyword token.Token
+}
+
+func (e *ThisExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitThisExpr(e)
+}
+
+// UnaryExpr is a prefix operation such as "-a" or "!a".
+type UnaryExpr struct {
+	Operator token.Token
+	Right    Expr
+}
+
+func (e *UnaryExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitUnaryExpr(e)
+}
+
+// AssignExpr stores a value into an existing variable: "name = value".
+type AssignExpr struct {
+	Name  token.Token
+	Value Expr
+}
+
+func (e *AssignExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitAssignExpr(e)
+}
+
+// LogicalExpr is a short-circuiting "and" or "or".
+type LogicalExpr struct {
+	Left     Expr
+	Operator token.Token
+	Right    Expr
+}
+
+func (e *LogicalExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitLogicalExpr(e)
+}
+
+// VariableExpr reads the value of a variable.
+type VariableExpr struct {
+	Name token.Token
+}
+
+func (e *VariableExpr) Accept(visitor ExprVisitor) (any, error) {
+	return visitor.VisitVariableExpr(e)
+}
+
+// ExprPos returns the file and line an expression is attributed to. The
+// file is empty unless a directive attributed the line to another file.
+func ExprPos(expr Expr) (file string, line int) {
+	switch e := expr.(type) {
+	case *AssignExpr:
+		return e.Name.File, e.Name.Line
+	case *BinaryExpr:
+		return e.Operator.File, e.Operator.Line
+	case *CallExpr:
+		return e.Paren.File, e.Paren.Line
+	case *ConditionalExpr:
+		return e.Question.File, e.Question.Line
+	case *FunctionExpr:
+		return e.Function.Name.File, e.Function.Name.Line
+	case *GetExpr:
+		return e.Name.File, e.Name.Line
+	case *GroupingExpr:
+		return ExprPos(e.Expression)
+	case *IncrementExpr:
+		return e.Operator.File, e.Operator.Line
+	case *InterpolationExpr:
+		return e.Start.File, e.Start.Line
+	case *LiteralExpr:
+		return e.File, e.Line
+	case *LogicalExpr:
+		return e.Operator.File, e.Operator.Line
+	case *SetExpr:
+		return e.Name.File, e.Name.Line
+	case *SuperExpr:
+		return e.Keyword.File, e.Keyword.Line
+	case *ThisExpr:
+		return e.Keyword.File, e.Keyword.Line
+	case *UnaryExpr:
+		return e.Operator.File, e.Operator.Line
+	case *VariableExpr:
+		return e.Name.File, e.Name.Line
+	}
+	return "", 0
+}
@@ -0,0 +1,262 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/internal/number"
+)
+
+// Printer renders syntax trees as parenthesized prefix expressions, e.g.
+// "(* (- 123.0) (group 45.67))", which makes precedence and nesting
+// explicit. It is the AstPrinter of Crafting Interpreters, extended to
+// statements:
+//
+//	(print e)  (; e)  (var name e)  (block s...)  (if c then else)
+//	(while c body)  (fun name (params...) body...)  (return e)
+//	(class Name < Super methods...)
+type Printer struct {
+	b strings.Builder
+}
+
+// PrintExpr returns the parenthesized form of expr.
+func (p *Printer) PrintExpr(expr Expr) string {
+	p.b.Reset()
+	p.expr(expr)
+	return p.b.String()
+}
+
+// PrintStmt returns the parenthesized form of stmt.
+func (p *Printer) PrintStmt(stmt Stmt) string {
+	p.b.Reset()
+	p.stmt(stmt)
+	return p.b.String()
+}
+
+func (p *Printer) expr(expr Expr) {
+	_, _ = expr.Accept(p)
+}
+
+func (p *Printer) stmt(stmt Stmt) {
+	_ = stmt.Accept(p)
+}
+
+// parenthesize writes "(name part part...)". Each part is an Expr, a Stmt,
+// a []Stmt, or anything else, which is written with fmt.
+func (p *Printer) parenthesize(name string, parts ...any) {
+	p.b.WriteString("(" + name)
+	for _, part := range parts {
+		p.b.WriteByte(' ')
+		switch part := part.(type) {
+		case Expr:
+			p.expr(part)
+		case Stmt:
+			p.stmt(part)
+		case []Stmt:
+			for i, stmt := range part {
+				if i > 0 {
+					p.b.WriteByte(' ')
+				}
+				p.stmt(stmt)
+			}
+		default:
+			fmt.Fprint(&p.b, part)
+		}
+	}
+	p.b.WriteByte(')')
+}
+
+func (p *Printer) VisitBlockStmt(stmt *BlockStmt) error {
+	p.parenthesize("block", stmt.Statements)
+	return nil
+}
+
+func (p *Printer) VisitBreakStmt(stmt *BreakStmt) error {
+	p.parenthesize("break")
+	return nil
+}
+
+func (p *Printer) VisitClassStmt(stmt *ClassStmt) error {
+	parts := []any{stmt.Name.Lexeme}
+	if stmt.Superclass != nil {
+		parts = append(parts, "<", stmt.Superclass.Name.Lexeme)
+	}
+	for _, method := range stmt.Methods {
+		parts = append(parts, method)
+	}
+	p.parenthesize("class", parts...)
+	return nil
+}
+
+func (p *Printer) VisitContinueStmt(stmt *ContinueStmt) error {
+	p.parenthesize("continue")
+	return nil
+}
+
+func (p *Printer) VisitExpressionStmt(stmt *ExpressionStmt) error {
+	p.parenthesize(";", stmt.Expression)
+	return nil
+}
+
+func (p *Printer) VisitFunctionStmt(stmt *FunctionStmt) error {
+	p.function([]any{stmt.Name.Lexeme}, stmt)
+	return nil
+}
+
+// function prints a function as "(fun name (params) body)", with parts
+// before the parameters.
+func (p *Printer) function(parts []any, stmt *FunctionStmt) {
+	params := make([]string, len(stmt.Params))
+	for i, param := range stmt.Params {
+		params[i] = param.Lexeme
+	}
+	parts = append(parts, "("+strings.Join(params, " ")+")")
+	if len(stmt.Body) > 0 {
+		parts = append(parts, stmt.Body)
+	}
+	p.parenthesize("fun", parts...)
+}
+
+func (p *Printer) VisitIfStmt(stmt *IfStmt) error {
+	if stmt.ElseBranch == nil {
+		p.parenthesize("if", stmt.Condition, stmt.ThenBranch)
+	} else {
+		p.parenthesize("if", stmt.Condition, stmt.ThenBranch, stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (p *Printer) VisitPrintStmt(stmt *PrintStmt) error {
+	p.parenthesize("print", stmt.Expression)
+	return nil
+}
+
+func (p *Printer) VisitReturnStmt(stmt *ReturnStmt) error {
+	if stmt.Value == nil {
+		p.parenthesize("return")
+	} else {
+		p.parenthesize("return", stmt.Value)
+	}
+	return nil
+}
+
+func (p *Printer) VisitVarStmt(stmt *VarStmt) error {
+	if stmt.Initializer == nil {
+		p.parenthesize("var", stmt.Name.Lexeme)
+	} else {
+		p.parenthesize("var", stmt.Name.Lexeme, stmt.Initializer)
+	}
+	return nil
+}
+
+func (p *Printer) VisitWhileStmt(stmt *WhileStmt) error {
+	if stmt.Increment == nil {
+		p.parenthesize("while", stmt.Condition, stmt.Body)
+	} else {
+		p.parenthesize("while", stmt.Condition, stmt.Body, stmt.Increment)
+	}
+	return nil
+}
+
+func (p *Printer) VisitAssignExpr(expr *AssignExpr) (any, error) {
+	p.parenthesize("=", expr.Name.Lexeme, expr.Value)
+	return nil, nil
+}
+
+func (p *Printer) VisitBinaryExpr(expr *BinaryExpr) (any, error) {
+	p.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitCallExpr(expr *CallExpr) (any, error) {
+	parts := []any{expr.Callee}
+	for _, argument := range expr.Arguments {
+		parts = append(parts, argument)
+	}
+	p.parenthesize("call", parts...)
+	return nil, nil
+}
+
+func (p *Printer) VisitConditionalExpr(expr *ConditionalExpr) (any, error) {
+	p.parenthesize("?:", expr.Condition, expr.ThenBranch, expr.ElseBranch)
+	return nil, nil
+}
+
+func (p *Printer) VisitFunctionExpr(expr *FunctionExpr) (any, error) {
+	p.function(nil, expr.Function)
+	return nil, nil
+}
+
+func (p *Printer) VisitGetExpr(expr *GetExpr) (any, error) {
+	p.parenthesize(".", expr.Object, expr.Name.Lexeme)
+	return nil, nil
+}
+
+func (p *Printer) VisitGroupingExpr(expr *GroupingExpr) (any, error) {
+	p.parenthesize("group", expr.Expression)
+	return nil, nil
+}
+
+func (p *Printer) VisitIncrementExpr(expr *IncrementExpr) (any, error) {
+	if expr.Prefix {
+		p.parenthesize(expr.Operator.Lexeme, expr.Target)
+	} else {
+		p.parenthesize("postfix"+expr.Operator.Lexeme, expr.Target)
+	}
+	return nil, nil
+}
+
+func (p *Printer) VisitInterpolationExpr(expr *InterpolationExpr) (any, error) {
+	parts := make([]any, len(expr.Parts))
+	for i, part := range expr.Parts {
+		parts[i] = part
+	}
+	p.parenthesize("interpolate", parts...)
+	return nil, nil
+}
+
+func (p *Printer) VisitLiteralExpr(expr *LiteralExpr) (any, error) {
+	switch v := expr.Value.(type) {
+	case nil:
+		p.b.WriteString("nil")
+	case float64:
+		p.b.WriteString(number.FormatLiteral(v))
+	default:
+		fmt.Fprint(&p.b, v)
+	}
+	return nil, nil
+}
+
+func (p *Printer) VisitLogicalExpr(expr *LogicalExpr) (any, error) {
+	p.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitSetExpr(expr *SetExpr) (any, error) {
+	p.b.WriteString("(= ")
+	p.parenthesize(".", expr.Object, expr.Name.Lexeme)
+	p.b.WriteByte(' ')
+	p.expr(expr.Value)
+	p.b.WriteByte(')')
+	return nil, nil
+}
+
+func (p *Printer) VisitSuperExpr(expr *SuperExpr) (any, error) {
+	p.parenthesize("super", expr.Method.Lexeme)
+	return nil, nil
+}
+
+func (p *Printer) VisitThisExpr(expr *ThisExpr) (any, error) {
+	p.b.WriteString("this")
+	return nil, nil
+}
+
+func (p *Printer) VisitUnaryExpr(expr *UnaryExpr) (any, error) {
+	p.parenthesize(expr.Operator.Lexeme, expr.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitVariableExpr(expr *VariableExpr) (any, error) {
+	p.b.WriteString(expr.Name.Lexeme)
+	return nil, nil
+}
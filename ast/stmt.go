@@ -0,0 +1,140 @@
+package ast
+
+import "github.com/kriyanshii/interpreter-go/token"
+
+// Stmt is a node in the statement syntax tree. Each concrete node calls
+// the matching method on the visitor it is given.
+type Stmt interface {
+	Accept(visitor StmtVisitor) error
+}
+
+// StmtVisitor is implemented by every pass that walks statements.
+type StmtVisitor interface {
+	VisitBlockStmt(stmt *BlockStmt) error
+	VisitBreakStmt(stmt *BreakStmt) error
+	VisitClassStmt(stmt *ClassStmt) error
+	VisitContinueStmt(stmt *ContinueStmt) error
+	VisitExpressionStmt(stmt *ExpressionStmt) error
+	VisitFunctionStmt(stmt *FunctionStmt) error
+	VisitIfStmt(stmt *IfStmt) error
+	VisitPrintStmt(stmt *PrintStmt) error
+	VisitReturnStmt(stmt *ReturnStmt) error
+	VisitVarStmt(stmt *VarStmt) error
+	VisitWhileStmt(stmt *WhileStmt) error
+}
+
+// BlockStmt is a braced list of statements with its own scope.
+type BlockStmt struct {
+	Statements []Stmt
+}
+
+func (s *BlockStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBlockStmt(s)
+}
+
+// BreakStmt leaves the innermost enclosing loop.
+type BreakStmt struct {
+	Keyword token.Token
+}
+
+func (s *BreakStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBreakStmt(s)
+}
+
+// ClassStmt declares a class. Superclass is nil when the class does not
+// inherit.
+type ClassStmt struct {
+	Name       token.Token
+	Superclass *VariableExpr
+	Methods    []*FunctionStmt
+}
+
+func (s *ClassStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitClassStmt(s)
+}
+
+// ContinueStmt skips the rest of the body of the innermost enclosing loop,
+// going on to its increment, if it has one, and condition.
+type ContinueStmt struct {
+	Keyword token.Token
+}
+
+func (s *ContinueStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitContinueStmt(s)
+}
+
+// ExpressionStmt evaluates an expression for its side effects.
+type ExpressionStmt struct {
+	Expression Expr
+}
+
+func (s *ExpressionStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitExpressionStmt(s)
+}
+
+// FunctionStmt declares a named function.
+type FunctionStmt struct {
+	Name   token.Token
+	Params []token.Token
+	Body   []Stmt
+}
+
+func (s *FunctionStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitFunctionStmt(s)
+}
+
+// IfStmt runs ThenBranch when Condition is truthy and ElseBranch, which may
+// be nil, otherwise.
+type IfStmt struct {
+	Condition  Expr
+	ThenBranch Stmt
+	ElseBranch Stmt
+}
+
+func (s *IfStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitIfStmt(s)
+}
+
+// PrintStmt writes the value of an expression to standard output.
+type PrintStmt struct {
+	Expression Expr
+}
+
+func (s *PrintStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitPrintStmt(s)
+}
+
+// ReturnStmt leaves the enclosing function. Value is nil for a bare
+// "return;".
+type ReturnStmt struct {
+	Keyword token.Token
+	Value   Expr
+}
+
+func (s *ReturnStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitReturnStmt(s)
+}
+
+// VarStmt declares a variable. Initializer is nil when none was written.
+type VarStmt struct {
+	Name        token.Token
+	Initializer Expr
+}
+
+func (s *VarStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitVarStmt(s)
+}
+
+// WhileStmt runs Body for as long as Condition is truthy. For loops are
+// desugared into while loops by the parser, keeping their Increment, which
+// is evaluated after each run of Body, even one cut short by continue. It
+// is nil for while loops.
+type WhileStmt struct {
+	Condition Expr
+	Body      Stmt
+	Increment Expr
+}
+
+func (s *WhileStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitWhileStmt(s)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// command describes a subcommand of golox, for the usage message and for
+// shell completion.
+type command struct {
+	name string
+	// args is how the usage message shows the arguments.
+	args string
+	// complete says how to complete each argument: argScript for scripts,
+	// argDir for directories, argNone for nothing, or the words the
+	// argument may be. The last entry goes for any further arguments.
+	complete []string
+}
+
+const (
+	argScript = "<script>"
+	argDir    = "<dir>"
+	argNone   = ""
+)
+
+// commands lists the subcommands in the order usage shows them. Running a
+// script needs no subcommand: "golox script.lox" is "golox run script.lox".
+var commands = []command{
+	{"run", "<script>", []string{argScript, argNone}},
+	{"tokenize", "<script>", []string{argScript, argNone}},
+	{"parse", "<script>", []string{argScript, argNone}},
+	{"evaluate", "<expression-file>", []string{argScript, argNone}},
+	{"diff", "<old> <new>", []string{argScript, argScript, argNone}},
+	{"similarity", "<dir>", []string{argDir, argNone}},
+	{"grep", "<kind:name> <dir>", []string{"call: assign: inherits:", argScript, argNone}},
+	{"scaffold", "class <name> [field...]", []string{"class", argNone}},
+	{"normalize", "<script>", []string{argScript, argNone}},
+	{"completion", "bash|zsh|fish", []string{"bash zsh fish", argNone}},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: golox [flags] [script]")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "       golox [flags] %s %s\n", c.name, c.args)
+	}
+	fmt.Fprintln(os.Stderr)
+	flag.PrintDefaults()
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeCompletion writes a script that teaches shell to complete golox's
+// subcommands, their arguments and the flags in flags.
+func writeCompletion(w io.Writer, shell string, flags *flag.FlagSet) error {
+	var b strings.Builder
+	switch shell {
+	case "bash":
+		writeBashCompletion(&b, flags)
+	case "zsh":
+		writeZshCompletion(&b, flags)
+	case "fish":
+		writeFishCompletion(&b, flags)
+	default:
+		return fmt.Errorf("can't write completions for %q, expected bash, zsh or fish", shell)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// completionFlags returns the flags as "--name", and the case pattern
+// matching those that take a value, such as "-format|--format".
+func completionFlags(flags *flag.FlagSet) (names []string, valued string) {
+	var patterns []string
+	flags.VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+			patterns = append(patterns, "-"+f.Name, "--"+f.Name)
+		}
+	})
+	return names, strings.Join(patterns, "|")
+}
+
+// writeArgumentCases writes the shell case arms that choose how to complete
+// an argument, matching "command:position" and setting kind. The arm for
+// no command offers the subcommands with offer, a format taking their
+// names.
+func writeArgumentCases(b *strings.Builder, offer string) {
+	names := make([]string, len(commands))
+	for n, c := range commands {
+		names[n] = c.name
+	}
+	fmt.Fprintf(b, "\t:0) "+offer+"; kind='%s' ;;\n", strings.Join(names, " "), argScript)
+	for _, c := range commands {
+		for n, kind := range c.complete {
+			position := fmt.Sprint(n + 1)
+			if n == len(c.complete)-1 {
+				position = "*"
+			}
+			fmt.Fprintf(b, "\t%s:%s) kind='%s' ;;\n", c.name, position, kind)
+		}
+	}
+}
+
+func writeBashCompletion(b *strings.Builder, flags *flag.FlagSet) {
+	names, valued := completionFlags(flags)
+	b.WriteString(`# bash completion for golox, generated by "golox completion bash".
+_golox() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	local args=() kind='' i
+	case $prev in
+	` + valued + `) return ;;
+	esac
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W "` + strings.Join(names, " ") + `" -- "$cur"))
+		return
+	fi
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case ${COMP_WORDS[i]} in
+		` + valued + `) ((i++)) ;;
+		-*) ;;
+		*) args+=("${COMP_WORDS[i]}") ;;
+		esac
+	done
+	COMPREPLY=()
+	case ${args[0]}:${#args[@]} in
+`)
+	writeArgumentCases(b, `COMPREPLY=($(compgen -W "%s" -- "$cur"))`)
+	b.WriteString(`	esac
+	case $kind in
+	'` + argScript + `') COMPREPLY+=($(compgen -d -- "$cur") $(compgen -f -X '!*.lox' -- "$cur")) ;;
+	'` + argDir + `') COMPREPLY+=($(compgen -d -- "$cur")) ;;
+	'') ;;
+	*) COMPREPLY+=($(compgen -W "$kind" -- "$cur")) ;;
+	esac
+}
+complete -o filenames -F _golox golox
+`)
+}
+
+func writeZshCompletion(b *strings.Builder, flags *flag.FlagSet) {
+	names, valued := completionFlags(flags)
+	b.WriteString(`#compdef golox
+# zsh completion for golox, generated by "golox completion zsh".
+_golox() {
+	local -a args
+	local kind='' i
+	case ${words[CURRENT-1]} in
+	` + valued + `) return ;;
+	esac
+	if [[ ${words[CURRENT]} == -* ]]; then
+		compadd -- ` + strings.Join(names, " ") + `
+		return
+	fi
+	for ((i = 2; i < CURRENT; i++)); do
+		case ${words[i]} in
+		` + valued + `) ((i++)) ;;
+		-*) ;;
+		*) args+=(${words[i]}) ;;
+		esac
+	done
+	case ${args[1]}:${#args} in
+`)
+	writeArgumentCases(b, "compadd -- %s")
+	b.WriteString(`	esac
+	case $kind in
+	'` + argScript + `') _files -g '*.lox' ;;
+	'` + argDir + `') _files -/ ;;
+	'') ;;
+	*) compadd -- ${=kind} ;;
+	esac
+}
+if [[ $funcstack[1] == _golox ]]; then
+	_golox "$@"
+else
+	compdef _golox golox
+fi
+`)
+}
+
+func writeFishCompletion(b *strings.Builder, flags *flag.FlagSet) {
+	b.WriteString("# fish completion for golox, generated by \"golox completion fish\".\n")
+	b.WriteString("complete -c golox -f\n")
+	flags.VisitAll(func(f *flag.Flag) {
+		_, description := flag.UnquoteUsage(f)
+		fmt.Fprintf(b, "complete -c golox -l %s", f.Name)
+		if v, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !v.IsBoolFlag() {
+			b.WriteString(" -r")
+		}
+		fmt.Fprintf(b, " -d %s\n", fishQuote(description))
+	})
+
+	names := make([]string, len(commands))
+	for n, c := range commands {
+		names[n] = c.name
+	}
+	fmt.Fprintf(b, "complete -c golox -n __fish_use_subcommand -a %s\n", fishQuote(strings.Join(names, " ")))
+	fmt.Fprintf(b, "complete -c golox -n __fish_use_subcommand -a %s\n", fishQuote(fishArgument(argScript)))
+	// fish can't easily tell which argument is being completed, so offer
+	// whatever any of a command's arguments may be.
+	for _, c := range commands {
+		for _, kind := range c.complete {
+			if kind == argNone {
+				continue
+			}
+			fmt.Fprintf(b, "complete -c golox -n %s -a %s\n",
+				fishQuote("__fish_seen_subcommand_from "+c.name), fishQuote(fishArgument(kind)))
+		}
+	}
+}
+
+// fishArgument returns what fish should offer for an argument of kind.
+func fishArgument(kind string) string {
+	switch kind {
+	case argScript:
+		return "(__fish_complete_suffix .lox)"
+	case argDir:
+		return "(__fish_complete_directories)"
+	}
+	return kind
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configPath returns where the user's config file lives: golox/config.toml
+// in the user's config directory, which on Linux is ~/.config unless
+// $XDG_CONFIG_HOME says otherwise. $GOLOX_CONFIG overrides it, and set to
+// the empty string turns the config file off.
+func configPath() string {
+	if path, ok := os.LookupEnv("GOLOX_CONFIG"); ok {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "golox", "config.toml")
+}
+
+// loadConfig applies the settings in the config file at path to flags, as
+// defaults: it runs before the command line is parsed, so flags given there
+// win. Each setting is named after a flag and written in TOML:
+//
+//	dialect = ["bigint"]
+//	strict = true
+//	theme = "dark"
+//	prompt = "{line}> "
+//
+// Settings may also be grouped in tables such as [repl]; the table names
+// are only for readability. A missing file is not an error.
+func loadConfig(path string, flags *flag.FlagSet) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	settings, err := parseConfig(f)
+	if err != nil {
+		return fmt.Errorf("%s:%v", path, err)
+	}
+	for _, s := range settings {
+		if flags.Lookup(s.name) == nil {
+			return fmt.Errorf("%s:%d: unknown setting %q", path, s.line, s.name)
+		}
+		if err := flags.Set(s.name, s.value); err != nil {
+			return fmt.Errorf("%s:%d: bad value for %s: %v", path, s.line, s.name, err)
+		}
+	}
+	return nil
+}
+
+// configSetting is one "name = value" line of a config file, with the
+// value as it would be given on the command line.
+type configSetting struct {
+	name, value string
+	line        int
+}
+
+// parseConfig reads the subset of TOML that settings need: comments,
+// table headers, and keys set to strings, booleans, numbers or arrays of
+// strings, which are joined with commas.
+func parseConfig(r io.Reader) ([]configSetting, error) {
+	var settings []configSetting
+	lines := bufio.NewScanner(r)
+	for n := 1; lines.Scan(); n++ {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(stripComment(line), "]") {
+				return nil, fmt.Errorf("%d: malformed table header", n)
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%d: expected name = value", n)
+		}
+		value, err := configValue(stripComment(strings.TrimSpace(value)))
+		if err != nil {
+			return nil, fmt.Errorf("%d: %v", n, err)
+		}
+		settings = append(settings, configSetting{strings.Trim(strings.TrimSpace(name), `"`), value, n})
+	}
+	return settings, lines.Err()
+}
+
+// configValue turns a TOML value into the text of a flag.
+func configValue(text string) (string, error) {
+	switch {
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return "", errors.New("unterminated array")
+		}
+		var items []string
+		for _, item := range strings.Split(text[1:len(text)-1], ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			value, err := configString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, value)
+		}
+		return strings.Join(items, ","), nil
+	case strings.HasPrefix(text, `"`), strings.HasPrefix(text, "'"):
+		return configString(text)
+	case text == "true", text == "false":
+		return text, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64); err == nil {
+		return strings.ReplaceAll(text, "_", ""), nil
+	}
+	return "", fmt.Errorf("can't read the value %s", text)
+}
+
+// configString reads a TOML string: a basic string in double quotes, with
+// escapes, or a literal string in single quotes, without.
+func configString(text string) (string, error) {
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' && !strings.Contains(text[1:len(text)-1], "'") {
+		return text[1 : len(text)-1], nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if s, err := strconv.Unquote(text); err == nil {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("can't read the string %s", text)
+}
+
+// stripComment removes a trailing # comment, leaving any # inside a
+// string alone.
+func stripComment(text string) string {
+	var quote rune
+	escaped := false
+	for i, c := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"', c == '\'':
+			quote = c
+		case c == '#':
+			return strings.TrimSpace(text[:i])
+		}
+	}
+	return text
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+// printCostReport writes an annotated listing of the program to stderr,
+// each line prefixed with the number of expression evaluations attributed
+// to it during the run. The script comes first, followed by each other
+// file that code was attributed to, by //#include or //#line, under its
+// name. The hottest line, the earliest of them on a tie, is marked so it
+// stands out in long files.
+func (l *Lox) printCostReport(costs map[interpreter.SourceLine]int) {
+	lines := make([]interpreter.SourceLine, 0, len(costs))
+	for line := range costs {
+		lines = append(lines, line)
+	}
+	slices.SortFunc(lines, func(a, b interpreter.SourceLine) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+	})
+	total, most, hottest := 0, 0, interpreter.SourceLine{}
+	for _, line := range lines {
+		total += costs[line]
+		if costs[line] > most {
+			most, hottest = costs[line], line
+		}
+	}
+
+	fmt.Fprintf(l.stderr, "-- cost report: %d evaluations --\n", total)
+	files := []string{""}
+	for _, line := range lines {
+		if line.File != files[len(files)-1] {
+			files = append(files, line.File)
+		}
+	}
+	for _, file := range files {
+		if file != "" {
+			fmt.Fprintf(l.stderr, "-- %s --\n", file)
+		}
+		texts := l.sourceLines(file)
+		if n := len(texts); n > 0 && texts[n-1] == "" {
+			texts = texts[:n-1]
+		}
+		for n := range texts {
+			l.printCost(costs, interpreter.SourceLine{File: file, Line: n + 1}, hottest, strings.TrimSuffix(texts[n], "\r"))
+		}
+		// A //#line directive can name a file that can't be read, or lines
+		// past its end; list the lines that were counted all the same.
+		for _, line := range lines {
+			if line.File == file && line.Line > len(texts) {
+				l.printCost(costs, line, hottest, fmt.Sprintf("(line %d)", line.Line))
+			}
+		}
+	}
+}
+
+// printCost writes one line of a cost report.
+func (l *Lox) printCost(costs map[interpreter.SourceLine]int, line, hottest interpreter.SourceLine, text string) {
+	marker := " "
+	if line == hottest {
+		marker = "*"
+	}
+	if count, ok := costs[line]; ok {
+		fmt.Fprintf(l.stderr, "%10d %s| %s\n", count, marker, text)
+	} else {
+		fmt.Fprintf(l.stderr, "%10s %s| %s\n", "", marker, text)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+func TestCostReport(t *testing.T) {
+	lib := filepath.Join(t.TempDir(), "lib.lox")
+	if err := os.WriteFile(lib, []byte("fun f() {\n  return 1 + 2;\n}\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "ties go to the earliest line",
+			source: "print 1;\nprint 2;\n",
+			want: "-- cost report: 2 evaluations --\n" +
+				"         1 *| print 1;\n" +
+				"         1  | print 2;\n",
+		},
+		{
+			name:   "included lines are listed under their file",
+			source: "//#include \"" + lib + "\"\nprint f();\nprint f();\n",
+			want: "-- cost report: 10 evaluations --\n" +
+				"            | //#include \"" + lib + "\"\n" +
+				"         2  | print f();\n" +
+				"         2  | print f();\n" +
+				"-- " + lib + " --\n" +
+				"            | fun f() {\n" +
+				"         6 *|   return 1 + 2;\n" +
+				"            | }\n",
+		},
+		{
+			name:   "directives can name files that can't be read",
+			source: "print 1;\n//#line 7 \"gen.lox\"\nprint 2 + 3;\n",
+			want: "-- cost report: 4 evaluations --\n" +
+				"         1  | print 1;\n" +
+				"            | //#line 7 \"gen.lox\"\n" +
+				"            | print 2 + 3;\n" +
+				"-- gen.lox --\n" +
+				"         3 *| (line 7)\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stderr strings.Builder
+			l := NewLox(ModeInterpret)
+			l.stdout, l.stderr = &strings.Builder{}, &stderr
+			l.interpreter = interpreter.New(l.stdout)
+			l.interpreter.LineCosts = map[interpreter.SourceLine]int{}
+			l.run(test.source, "")
+			l.printCostReport(l.interpreter.LineCosts)
+			if stderr.String() != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", stderr.String(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/lox"
+)
+
+// parseDialect reads the comma-separated feature list given to --dialect,
+// e.g. "bigint".
+func parseDialect(spec string) (lox.Dialect, error) {
+	var d lox.Dialect
+	for _, feature := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(feature) {
+		case "", "standard":
+		case "bigint":
+			d.BigInt = true
+		default:
+			return lox.Dialect{}, fmt.Errorf("unknown dialect feature %q", feature)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/kriyanshii/interpreter-go/internal/number"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// astChange is one structural difference between two syntax trees. A line
+// is 0 when the change has no counterpart on that side, e.g. the old line
+// of an added node.
+type astChange struct {
+	OldLine     int
+	NewLine     int
+	Description string
+}
+
+func (c astChange) String() string {
+	return fmt.Sprintf("%s:%s: %s", lineLabel(c.OldLine), lineLabel(c.NewLine), c.Description)
+}
+
+func lineLabel(line int) string {
+	if line == 0 {
+		return "-"
+	}
+	return fmt.Sprint(line)
+}
+
+// diffAST compares two syntax trees node by node and describes how the
+// second differs from the first. Layout, comments and line numbers play
+// no part in the comparison, so reformatting a file produces no changes.
+//
+// The trees are walked reflectively: every node is a pointer to a struct
+// whose fields are child nodes, slices of nodes, tokens or literal values,
+// so new node types are covered without touching this file.
+func diffAST(oldTree, newTree any) []astChange {
+	d := &astDiffer{}
+	d.diff(reflect.ValueOf(oldTree), reflect.ValueOf(newTree))
+	return d.changes
+}
+
+type astDiffer struct {
+	changes []astChange
+
+	// Lines of the innermost enclosing nodes, used for nodes such as
+	// literals that carry no token of their own.
+	oldLine, newLine int
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+func (d *astDiffer) add(oldNode, newNode reflect.Value, format string, args ...any) {
+	oldLine, newLine := nodeLine(oldNode), nodeLine(newNode)
+	if oldLine == 0 && oldNode.IsValid() {
+		oldLine = d.oldLine
+	}
+	if newLine == 0 && newNode.IsValid() {
+		newLine = d.newLine
+	}
+	change := astChange{OldLine: oldLine, NewLine: newLine, Description: fmt.Sprintf(format, args...)}
+	// A renamed variable is usually renamed at every use on a line; say so
+	// once.
+	for _, seen := range d.changes {
+		if seen == change {
+			return
+		}
+	}
+	d.changes = append(d.changes, change)
+}
+
+func (d *astDiffer) diff(a, b reflect.Value) {
+	a, b = unwrap(a), unwrap(b)
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return
+	case !a.IsValid():
+		d.add(a, b, "added %s", nodeName(b))
+		return
+	case !b.IsValid():
+		d.add(a, b, "removed %s", nodeName(a))
+		return
+	case a.Type() != b.Type():
+		d.add(a, b, "replaced %s with %s", nodeName(a), nodeName(b))
+		return
+	}
+
+	switch {
+	case a.Type() == tokenType:
+		d.diffToken(a, b, a.Interface().(token.Token), b.Interface().(token.Token))
+	case a.Kind() == reflect.Slice:
+		d.diffList(a, b)
+	case a.Kind() == reflect.Pointer && a.Elem().Kind() == reflect.Struct:
+		d.diffFields(a, b)
+	}
+}
+
+func (d *astDiffer) diffFields(a, b reflect.Value) {
+	savedOld, savedNew := d.oldLine, d.newLine
+	defer func() { d.oldLine, d.newLine = savedOld, savedNew }()
+	d.track(a, b)
+
+	structType := a.Elem().Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fa, fb := a.Elem().Field(i), b.Elem().Field(i)
+		switch {
+		case field.Type == tokenType:
+			d.diffToken(a, b, fa.Interface().(token.Token), fb.Interface().(token.Token))
+		case field.Type.Kind() == reflect.Interface && field.Type.NumMethod() == 0:
+			// An untyped field holds a literal value rather than a child node.
+			if !sameLiteral(fa.Interface(), fb.Interface()) {
+				d.add(a, b, "changed literal %s to %s", describeLiteral(fa.Interface()), describeLiteral(fb.Interface()))
+			}
+		case field.Type.Kind() == reflect.Interface, field.Type.Kind() == reflect.Pointer, field.Type.Kind() == reflect.Slice:
+			d.diff(fa, fb)
+		}
+	}
+}
+
+func (d *astDiffer) diffToken(a, b reflect.Value, ta, tb token.Token) {
+	if spelling(ta) == spelling(tb) {
+		return
+	}
+	if ta.Type == token.Identifier && tb.Type == token.Identifier {
+		d.add(a, b, "renamed %s to %s", ta.Lexeme, tb.Lexeme)
+		return
+	}
+	d.add(a, b, "changed '%s' to '%s' in %s", spelling(ta), spelling(tb), nodeName(a))
+}
+
+// diffList aligns two node lists on their longest common subsequence of
+// structurally equal nodes. Between aligned pairs, nodes of the same type
+// are diffed pairwise and the rest are reported as removed or added.
+func (d *astDiffer) diffList(a, b reflect.Value) {
+	n, m := a.Len(), b.Len()
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equalNodes(a.Index(i), b.Index(j)) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var removed, added []reflect.Value
+	flush := func() {
+		// Pair each removed node with the next added node of the same type
+		// so that edited statements are diffed rather than replaced.
+		k := 0
+		for _, node := range removed {
+			match := -1
+			for idx := k; idx < len(added); idx++ {
+				if unwrap(added[idx]).Type() == unwrap(node).Type() {
+					match = idx
+					break
+				}
+			}
+			if match < 0 {
+				d.add(node, reflect.Value{}, "removed %s", nodeName(unwrap(node)))
+				continue
+			}
+			for _, extra := range added[k:match] {
+				d.add(reflect.Value{}, extra, "added %s", nodeName(unwrap(extra)))
+			}
+			d.diff(node, added[match])
+			d.track(node, added[match])
+			k = match + 1
+		}
+		for _, extra := range added[k:] {
+			d.add(reflect.Value{}, extra, "added %s", nodeName(unwrap(extra)))
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && equalNodes(a.Index(i), b.Index(j)):
+			flush()
+			d.track(a.Index(i), b.Index(j))
+			i++
+			j++
+		case j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, a.Index(i))
+			i++
+		default:
+			added = append(added, b.Index(j))
+			j++
+		}
+	}
+	flush()
+}
+
+// track records the lines of the latest aligned list elements so nodes
+// without tokens of their own can be reported near their neighbours.
+func (d *astDiffer) track(a, b reflect.Value) {
+	if line := nodeLine(a); line > 0 {
+		d.oldLine = line
+	}
+	if line := nodeLine(b); line > 0 {
+		d.newLine = line
+	}
+}
+
+// equalNodes reports whether two subtrees have the same structure, ignoring
+// where their tokens appear in the source.
+func equalNodes(a, b reflect.Value) bool {
+	a, b = unwrap(a), unwrap(b)
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch {
+	case a.Type() == tokenType:
+		ta, tb := a.Interface().(token.Token), b.Interface().(token.Token)
+		return ta.Type == tb.Type && spelling(ta) == spelling(tb)
+	case a.Kind() == reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalNodes(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case a.Kind() == reflect.Pointer && a.Elem().Kind() == reflect.Struct:
+		for i := 0; i < a.Elem().NumField(); i++ {
+			field := a.Elem().Type().Field(i)
+			fa, fb := a.Elem().Field(i), b.Elem().Field(i)
+			switch {
+			case field.Type.Kind() == reflect.Interface && field.Type.NumMethod() == 0:
+				if !sameLiteral(fa.Interface(), fb.Interface()) {
+					return false
+				}
+			case field.Type == tokenType, field.Type.Kind() == reflect.Interface, field.Type.Kind() == reflect.Pointer, field.Type.Kind() == reflect.Slice:
+				if !equalNodes(fa, fb) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// unwrap strips interfaces and turns nil pointers, interfaces and slices
+// into the zero Value so callers only need one validity check.
+func unwrap(v reflect.Value) reflect.Value {
+	for v.IsValid() && v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	if v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Slice) && v.IsNil() {
+		return reflect.Value{}
+	}
+	return v
+}
+
+// nodeLine returns the line of the earliest token in a subtree, or 0 if it
+// has none.
+func nodeLine(v reflect.Value) int {
+	v = unwrap(v)
+	if !v.IsValid() {
+		return 0
+	}
+
+	line := 0
+	consider := func(l int) {
+		if l > 0 && (line == 0 || l < line) {
+			line = l
+		}
+	}
+	switch {
+	case v.Type() == tokenType:
+		return v.Interface().(token.Token).Line
+	case v.Kind() == reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			consider(nodeLine(v.Index(i)))
+		}
+	case v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Struct:
+		for i := 0; i < v.Elem().NumField(); i++ {
+			field := v.Elem().Type().Field(i)
+			switch {
+			case field.Name == "Line" && field.Type.Kind() == reflect.Int:
+				consider(int(v.Elem().Field(i).Int()))
+			case field.IsExported():
+				consider(nodeLine(v.Elem().Field(i)))
+			}
+		}
+	}
+	return line
+}
+
+// nodeName turns a node type such as *BinaryExpr into "binary expression".
+func nodeName(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nothing"
+	}
+	t := v.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice {
+		return "list"
+	}
+
+	name := t.Name()
+	kind := ""
+	for suffix, noun := range map[string]string{"Expr": "expression", "Stmt": "statement"} {
+		if strings.HasSuffix(name, suffix) {
+			name, kind = strings.TrimSuffix(name, suffix), noun
+		}
+	}
+
+	var words []string
+	start := 0
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			words = append(words, strings.ToLower(name[start:i]))
+			start = i
+		}
+	}
+	words = append(words, strings.ToLower(name[start:]))
+	if kind != "" {
+		words = append(words, kind)
+	}
+	return strings.Join(words, " ")
+}
+
+func sameLiteral(a, b any) bool {
+	if x, ok := a.(*big.Int); ok {
+		y, ok := b.(*big.Int)
+		return ok && x.Cmp(y) == 0
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && a == b
+}
+
+func describeLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case float64:
+		return number.FormatLiteral(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// runDiff prints the structural changes between two files. Like diff(1) it
+// exits 1 when they differ.
+func (l *Lox) runDiff(oldPath, newPath string) {
+	oldTree := l.parseFile(oldPath)
+	newTree := l.parseFile(newPath)
+	if l.hadError {
+		os.Exit(65)
+	}
+
+	changes := diffAST(oldTree, newTree)
+	for _, change := range changes {
+		fmt.Fprintln(l.stdout, change)
+	}
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+}
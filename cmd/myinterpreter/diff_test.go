@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := "var a = 1;\nprint a;\nfun f(x) { return x; }\n"
+	tests := []struct {
+		name, new, want string
+		status          int
+	}{
+		{name: "same", new: old, status: 0},
+		{name: "only layout and comments differ", new: "var a   =  1;\n\n\nprint a; // c\nfun f(x) {\n return x;\n}\n", status: 0},
+		{name: "changes", new: "var a = 2;\nprint a;\nfun f(x, y) { return x + y; }\nprint \"new\";\n",
+			want: "1:1: changed literal 1.0 to 2.0\n" +
+				"-:3: added token\n" +
+				"3:3: replaced variable expression with binary expression\n" +
+				"-:4: added print statement\n",
+			status: 1},
+		{name: "removed statement", new: "var a = 1;\nfun f(x) { return x; }\n",
+			want: "2:-: removed print statement\n", status: 1},
+		{name: "syntax error", new: "print ;", status: 65},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"old.lox": old, "new.lox": test.new})
+			stdout, stderr, status := golox(t, dir, "diff", "old.lox", "new.lox")
+			if stdout != test.want || status != test.status {
+				t.Errorf("got %q and status %d, want %q and status %d; stderr:\n%s", stdout, status, test.want, test.status, stderr)
+			}
+		})
+	}
+
+	dir := writeFiles(t, map[string]string{"old.lox": old})
+	if _, stderr, status := golox(t, dir, "diff", "old.lox", "missing.lox"); status != 1 || !strings.HasPrefix(stderr, "Error reading file:") {
+		t.Errorf("missing file: got status %d and stderr %q, want status 1 and an error", status, stderr)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// grepQuery is a structural search such as "call:print": Kind says what
+// sort of node to look for and Name the identifier it must involve.
+type grepQuery struct {
+	Kind string
+	Name string
+}
+
+// grepKinds describes each kind of query, for error messages.
+var grepKinds = map[string]string{
+	"call":     "calls to a function or method",
+	"assign":   "assignments to a variable or field",
+	"inherits": "classes that inherit from a class",
+}
+
+func parseGrepQuery(spec string) (grepQuery, error) {
+	kind, name, ok := strings.Cut(spec, ":")
+	if _, known := grepKinds[kind]; !ok || !known || name == "" {
+		kinds := make([]string, 0, len(grepKinds))
+		for kind, description := range grepKinds {
+			kinds = append(kinds, fmt.Sprintf("%s:NAME (%s)", kind, description))
+		}
+		sort.Strings(kinds)
+		return grepQuery{}, fmt.Errorf("bad query %q, expected one of %s", spec, strings.Join(kinds, ", "))
+	}
+	return grepQuery{kind, name}, nil
+}
+
+// match reports whether node is what the query looks for, and if so the
+// token to report it at.
+func (q grepQuery) match(node any) (token.Token, bool) {
+	switch n := node.(type) {
+	case *ast.CallExpr:
+		if q.Kind != "call" {
+			break
+		}
+		switch callee := n.Callee.(type) {
+		case *ast.VariableExpr:
+			return callee.Name, callee.Name.Lexeme == q.Name
+		case *ast.GetExpr:
+			return callee.Name, callee.Name.Lexeme == q.Name
+		case *ast.SuperExpr:
+			return callee.Method, callee.Method.Lexeme == q.Name
+		}
+	case *ast.AssignExpr:
+		return n.Name, q.Kind == "assign" && n.Name.Lexeme == q.Name
+	case *ast.SetExpr:
+		return n.Name, q.Kind == "assign" && n.Name.Lexeme == q.Name
+	case *ast.IncrementExpr:
+		if q.Kind != "assign" {
+			break
+		}
+		switch target := n.Target.(type) {
+		case *ast.VariableExpr:
+			return target.Name, target.Name.Lexeme == q.Name
+		case *ast.GetExpr:
+			return target.Name, target.Name.Lexeme == q.Name
+		}
+	case *ast.ClassStmt:
+		return n.Name, q.Kind == "inherits" && n.Superclass != nil && n.Superclass.Name.Lexeme == q.Name
+	}
+	return token.Token{}, false
+}
+
+// walkAST calls visit on every node of a syntax tree, parents before their
+// children. Like diffAST it walks the tree reflectively, so new node types
+// are covered without touching this file.
+func walkAST(tree any, visit func(node any)) {
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		v = unwrap(v)
+		switch {
+		case !v.IsValid():
+		case v.Kind() == reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				walk(v.Index(i))
+			}
+		case v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Struct:
+			visit(v.Interface())
+			for i := 0; i < v.Elem().NumField(); i++ {
+				switch v.Elem().Type().Field(i).Type.Kind() {
+				case reflect.Interface, reflect.Pointer, reflect.Slice:
+					walk(v.Elem().Field(i))
+				}
+			}
+		}
+	}
+	walk(reflect.ValueOf(tree))
+}
+
+// runGrep searches every .lox file under dir, or the single file dir names,
+// for the nodes spec asks for, and prints each as "path:line:col: text"
+// in the manner of grep -n. Like grep it exits 1 when nothing matched.
+// Files that do not parse are reported and left out.
+func (l *Lox) runGrep(spec, dir string) {
+	query, err := parseGrepQuery(spec)
+	if err != nil {
+		fmt.Fprintf(l.stderr, "golox: %v\n", err)
+		os.Exit(64)
+	}
+
+	found := false
+	for _, path := range l.loxFiles(dir) {
+		l.hadError = false
+		program := l.parseFile(path)
+		if l.hadError {
+			fmt.Fprintf(l.stderr, "Skipping %s: it has syntax errors.\n", path)
+			continue
+		}
+
+		var matches []token.Token
+		walkAST(program, func(node any) {
+			if tok, ok := query.match(node); ok {
+				matches = append(matches, tok)
+			}
+		})
+		sort.SliceStable(matches, func(i, j int) bool {
+			a, b := matches[i], matches[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+
+		for _, tok := range matches {
+			found = true
+			// A //#line directive may attribute the match to another file.
+			where := path
+			if tok.File != "" {
+				where = tok.File
+			}
+			text := ""
+			if lines := l.sourceLines(tok.File); tok.Line >= 1 && tok.Line <= len(lines) {
+				text = strings.TrimSpace(lines[tok.Line-1])
+			}
+			fmt.Fprintf(l.stdout, "%s:%d:%d: %s\n", where, tok.Line, tok.Column, text)
+		}
+	}
+	if !found {
+		os.Exit(1)
+	}
+}
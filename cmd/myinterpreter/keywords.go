@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kriyanshii/interpreter-go/scanner"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// loadAliases reads a keyword file, which gives words to use in place of
+// the keywords, one per line in the format of the config file:
+//
+//	# Spanish
+//	si = "if"
+//	sino = "else"
+//	mientras = "while"
+func loadAliases(path string) (scanner.Aliases, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	settings, err := parseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", path, err)
+	}
+	table := make(map[string]string, len(settings))
+	for _, s := range settings {
+		if _, ok := table[s.name]; ok {
+			return nil, fmt.Errorf("%s:%d: %q is given twice", path, s.line, s.name)
+		}
+		table[s.name] = s.value
+	}
+	aliases, err := scanner.NewAliases(table)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return aliases, nil
+}
+
+// spelling is how tok is written in standard Lox: a keyword written with
+// an alias is spelled as the keyword itself, so that the tools comparing
+// code see no difference.
+func spelling(tok token.Token) string {
+	if keyword := tok.Type.Keyword(); keyword != "" {
+		return keyword
+	}
+	return tok.Lexeme
+}
+
+// runNormalize prints the script at path with the keywords spelled as in
+// standard Lox rather than as the -keywords file allows, so that it can be
+// shared with anyone.
+func (l *Lox) runNormalize(path string) {
+	l.source = l.readSource(path)
+	normalized := scanner.Normalize(l.source, l.aliases, l.error)
+	if l.hadError {
+		os.Exit(65)
+	}
+	fmt.Fprint(l.stdout, normalized)
+}
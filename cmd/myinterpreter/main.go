@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/interpreter"
+	"github.com/kriyanshii/interpreter-go/lox"
+	"github.com/kriyanshii/interpreter-go/parser"
+	"github.com/kriyanshii/interpreter-go/scanner"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// Mode selects what the interpreter does with the source it is given.
+type Mode int
+
+const (
+	// ModeTokenize prints every token, then exits 65 if scanning failed.
+	ModeTokenize Mode = iota
+	// ModeInterpret runs a script file, then exits 65 if it failed to
+	// compile or 70 if it failed at runtime.
+	ModeInterpret
+	// ModeREPL reads and runs one line at a time from stdin.
+	ModeREPL
+	// ModeParse prints the syntax tree of a file.
+	ModeParse
+	// ModeEvaluate evaluates a file holding a single expression and prints
+	// its value.
+	ModeEvaluate
+	// ModeDiff compares the syntax trees of two files.
+	ModeDiff
+	// ModeSimilarity compares the fingerprints of every file in a directory.
+	ModeSimilarity
+	// ModeGrep searches the syntax trees of files for a kind of node.
+	ModeGrep
+	// ModeScaffold writes the skeleton of a class to a file.
+	ModeScaffold
+	// ModeNormalize prints a script with its keywords spelled as usual.
+	ModeNormalize
+)
+
+// Lox holds the state shared by every stage of the pipeline, most notably
+// whether an error has been reported.
+type Lox struct {
+	mode        Mode
+	stdout      io.Writer
+	stderr      io.Writer
+	interpreter *interpreter.Interpreter
+	dialect     lox.Dialect
+	// aliases are the words the -keywords file lets scripts use for
+	// keywords.
+	aliases    scanner.Aliases
+	costReport bool
+	// format is how tokenize prints tokens: "text" or "json".
+	format string
+	// style is how the REPL looks.
+	style replStyle
+
+	// source is the program being run, and files caches the lines of
+	// other files errors were found in, for printing snippets.
+	source string
+	files  map[string][]string
+
+	hadError        bool
+	hadRuntimeError bool
+	// exited is set once a script calls exit, with the status it gave.
+	exited   bool
+	exitCode int
+}
+
+func NewLox(mode Mode) *Lox {
+	l := &Lox{mode: mode, stdout: os.Stdout, stderr: os.Stderr, style: replStyle{prompt: "> "}}
+	l.interpreter = interpreter.New(l.stdout)
+	return l
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run does the work of main and returns the status to exit with, so that
+// deferred cleanup, such as closing the audit log, happens before exiting.
+func run() int {
+	costReport := flag.Bool("cost-report", false, "after running a script, print how many evaluations each line cost")
+	mutationLog := flag.Int("mutation-log", 0, "on a runtime error, also print the last `n` variable assignments")
+	allowNet := flag.Bool("allow-net", false, "let scripts open network connections")
+	allowFS := flag.Bool("allow-fs", false, "let scripts read and change files")
+	graphics := flag.Bool("graphics", false, "let scripts draw on canvases and save them as images")
+	format := flag.String("format", "text", "how tokenize prints tokens: text or json")
+	terminal := flag.Bool("terminal", false, "let scripts move the cursor, set colors and read single key presses")
+	auditLog := flag.String("audit-log", "", "append a JSON line to `file` for every file and network native a script calls")
+	dialectSpec := flag.String("dialect", "", "comma-separated language extensions to enable (bigint)")
+	strict := flag.Bool("strict", false, "make reading a variable that was declared without a value, and not assigned since, an error")
+	prompt := flag.String("prompt", "> ", "the REPL prompt; {line} stands for the line number and {error} for ! after a failure")
+	continuation := flag.String("prompt2", "", "the prompt for each line of REPL paste mode, with the same placeholders as -prompt")
+	themeName := flag.String("theme", "none", "colors for the REPL prompt and errors: none, dark or light")
+	keywordFile := flag.String("keywords", "", "read words to use in place of the keywords, such as si for if, from `file`")
+	flag.Usage = usage
+	if err := loadConfig(configPath(), flag.CommandLine); err != nil {
+		fmt.Fprintf(os.Stderr, "golox: %v\n", err)
+		return 64
+	}
+	flag.Parse()
+
+	dialect, err := parseDialect(*dialectSpec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "golox: %v\n", err)
+		return 64
+	}
+	theme, err := parseTheme(*themeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "golox: %v\n", err)
+		return 64
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "golox: unknown format %q, expected text or json\n", *format)
+		return 64
+	}
+	var aliases scanner.Aliases
+	if *keywordFile != "" {
+		if aliases, err = loadAliases(*keywordFile); err != nil {
+			fmt.Fprintf(os.Stderr, "golox: %v\n", err)
+			return 64
+		}
+	}
+	var audit *interpreter.AuditLog
+	if *auditLog != "" {
+		f, err := os.OpenFile(*auditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "golox: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		audit = interpreter.NewAuditLog(f)
+	}
+	newLox := func(mode Mode) *Lox {
+		l := NewLox(mode)
+		l.dialect = dialect
+		l.aliases = aliases
+		l.interpreter.BigInt = dialect.BigInt
+		l.interpreter.Strict = *strict
+		l.interpreter.Audit = audit
+		if *allowNet {
+			l.interpreter.EnableNetwork()
+		}
+		if *allowFS {
+			l.interpreter.EnableFileSystem()
+		}
+		if *graphics {
+			l.interpreter.EnableGraphics()
+		}
+		if *terminal {
+			l.interpreter.EnableTerminal()
+		}
+		l.interpreter.EnableSignals()
+		return l
+	}
+
+	args := flag.Args()
+	switch {
+	case len(args) == 0:
+		l := newLox(ModeREPL)
+		l.style = replStyle{prompt: *prompt, continuation: *continuation, theme: theme}
+		return l.runPrompt(os.Stdin)
+	case len(args) == 2 && args[0] == "tokenize":
+		l := newLox(ModeTokenize)
+		l.format = *format
+		return l.runFile(args[1])
+	case len(args) == 2 && args[0] == "parse":
+		return newLox(ModeParse).runFile(args[1])
+	case len(args) == 2 && args[0] == "evaluate":
+		return newLox(ModeEvaluate).runFile(args[1])
+	case len(args) == 3 && args[0] == "diff":
+		newLox(ModeDiff).runDiff(args[1], args[2])
+	case len(args) == 2 && args[0] == "similarity":
+		newLox(ModeSimilarity).runSimilarity(args[1])
+	case len(args) == 3 && args[0] == "grep":
+		newLox(ModeGrep).runGrep(args[1], args[2])
+	case len(args) >= 3 && args[0] == "scaffold" && args[1] == "class":
+		newLox(ModeScaffold).runScaffold(args[2], args[3:])
+	case len(args) == 2 && args[0] == "normalize":
+		newLox(ModeNormalize).runNormalize(args[1])
+	case len(args) == 2 && args[0] == "completion":
+		if err := writeCompletion(os.Stdout, args[1], flag.CommandLine); err != nil {
+			fmt.Fprintf(os.Stderr, "golox: %v\n", err)
+			return 64
+		}
+	case len(args) == 2 && args[0] == "run", len(args) == 1:
+		l := newLox(ModeInterpret)
+		l.costReport = *costReport
+		if *mutationLog > 0 {
+			l.interpreter.Mutations = interpreter.NewMutationLog(*mutationLog)
+		}
+		return l.runFile(args[len(args)-1])
+	default:
+		usage()
+		return 64
+	}
+	return 0
+}
+
+func (l *Lox) readSource(path string) string {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(l.stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	return string(source)
+}
+
+// runFile runs the file at path and returns the status golox should exit
+// with.
+func (l *Lox) runFile(path string) int {
+	source := l.readSource(path)
+	if l.costReport {
+		l.interpreter.LineCosts = map[interpreter.SourceLine]int{}
+	}
+	l.run(source, path)
+	if l.costReport && !l.hadError {
+		l.printCostReport(l.interpreter.LineCosts)
+	}
+	return l.exitStatus()
+}
+
+// exitStatus returns the status a script asked for with exit, or else 65
+// after a compile error, 70 after a runtime error and 0 otherwise.
+func (l *Lox) exitStatus() int {
+	switch {
+	case l.exited:
+		return l.exitCode
+	case l.hadError:
+		return 65
+	case l.hadRuntimeError:
+		return 70
+	}
+	return 0
+}
+
+// scan tokenizes source. path names the file it was read from, if any, so
+// that includes can be resolved.
+func (l *Lox) scan(source, path string) []token.Token {
+	var mode scanner.Mode
+	if l.dialect.BigInt {
+		mode |= scanner.BigInts
+	}
+	var s *scanner.Scanner
+	if path == "" {
+		s = scanner.New(source, l.error, mode)
+	} else {
+		s = scanner.NewFile(source, path, l.error, mode)
+	}
+	s.SetAliases(l.aliases)
+	return s.ScanTokens()
+}
+
+// parseFile reads and parses the file at path, returning nil if it has
+// syntax errors.
+func (l *Lox) parseFile(path string) []ast.Stmt {
+	l.source = l.readSource(path)
+	return parser.New(l.scan(l.source, path), l.tokenError).Parse()
+}
+
+func (l *Lox) run(source, path string) {
+	l.source = source
+	tokens := l.scan(source, path)
+	if l.mode == ModeTokenize {
+		l.printTokens(tokens)
+		return
+	}
+
+	switch l.mode {
+	case ModeParse:
+		l.printTree(tokens)
+		return
+	case ModeEvaluate:
+		l.evaluate(tokens)
+		return
+	}
+
+	statements := parser.New(tokens, l.tokenError).Parse()
+	if l.hadError {
+		return
+	}
+	interpreter.NewResolver(l.interpreter, l.tokenError).Resolve(statements)
+	if l.hadError {
+		return
+	}
+	if err := l.interpreter.Interpret(statements); err != nil {
+		l.runtimeError(err)
+	}
+}
+
+// printTokens prints one token per line, either in the "TYPE lexeme
+// literal" format or, for --format=json, as a JSON array of objects.
+func (l *Lox) printTokens(tokens []token.Token) {
+	if l.format != "json" {
+		for _, tok := range tokens {
+			fmt.Fprintln(l.stdout, tok)
+		}
+		return
+	}
+
+	type jsonToken struct {
+		Type    string `json:"type"`
+		Lexeme  string `json:"lexeme"`
+		Literal any    `json:"literal"`
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+	}
+	var encoded bytes.Buffer
+	enc := json.NewEncoder(&encoded)
+	// Keep operators such as "<=" readable rather than "\u003c=".
+	enc.SetEscapeHTML(false)
+	fmt.Fprint(l.stdout, "[")
+	for i, tok := range tokens {
+		encoded.Reset()
+		if err := enc.Encode(jsonToken{tok.Type.String(), tok.Lexeme, tok.Literal, tok.Line, tok.Column}); err != nil {
+			panic(err) // every literal type is encodable
+		}
+		if i > 0 {
+			fmt.Fprint(l.stdout, ",")
+		}
+		fmt.Fprintf(l.stdout, "\n  %s", bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+	}
+	fmt.Fprintln(l.stdout, "\n]")
+}
+
+// printTree prints the syntax tree of a single expression, or failing that
+// of each statement of a program.
+func (l *Lox) printTree(tokens []token.Token) {
+	if l.hadError {
+		return
+	}
+	var printer ast.Printer
+	failed := false
+	if expr := parser.New(tokens, func(token.Token, string) { failed = true }).ParseExpression(); !failed {
+		fmt.Fprintln(l.stdout, printer.PrintExpr(expr))
+		return
+	}
+	for _, stmt := range parser.New(tokens, l.tokenError).Parse() {
+		fmt.Fprintln(l.stdout, printer.PrintStmt(stmt))
+	}
+}
+
+// evaluate evaluates tokens as a single expression and prints the result.
+func (l *Lox) evaluate(tokens []token.Token) {
+	expr := parser.New(tokens, l.tokenError).ParseExpression()
+	if l.hadError {
+		return
+	}
+	interpreter.NewResolver(l.interpreter, l.tokenError).ResolveExpr(expr)
+	if l.hadError {
+		return
+	}
+	value, err := l.interpreter.Evaluate(expr)
+	if err != nil {
+		l.runtimeError(err)
+		return
+	}
+	fmt.Fprintln(l.stdout, interpreter.Stringify(value))
+}
+
+func (l *Lox) error(file string, line, column int, message string) {
+	l.report(file, line, column, "", message)
+	l.printSnippet(file, line, column, "")
+}
+
+// tokenError reports a syntax error located at the given token.
+func (l *Lox) tokenError(tok token.Token, message string) {
+	if tok.Type == token.EOF {
+		l.report(tok.File, tok.Line, tok.Column, " at end", message)
+	} else {
+		l.report(tok.File, tok.Line, tok.Column, " at '"+tok.Lexeme+"'", message)
+	}
+	l.printSnippet(tok.File, tok.Line, tok.Column, tok.Lexeme)
+}
+
+// runtimeError reports an error raised while interpreting.
+func (l *Lox) runtimeError(err error) {
+	// exit() ends golox itself, at the REPL as in a script.
+	if exit, ok := err.(*interpreter.ExitError); ok {
+		l.exited, l.exitCode = true, exit.Code
+		return
+	}
+	if rt, ok := err.(*interpreter.RuntimeError); ok {
+		fmt.Fprintf(l.stderr, "%s\n[%s]\n", rt.Message, tokenLocation(rt.Token))
+		l.printSnippet(rt.Token.File, rt.Token.Line, rt.Token.Column, rt.Token.Lexeme)
+		printTrace(l.stderr, rt)
+	} else {
+		fmt.Fprintln(l.stderr, err)
+	}
+	if log := l.interpreter.Mutations; log != nil {
+		fmt.Fprintln(l.stderr, "Recent assignments, oldest first:")
+		log.Dump(l.stderr)
+	}
+	l.hadRuntimeError = true
+}
+
+// printTrace prints the calls a runtime error unwound through, innermost
+// first, each with the line it had reached:
+//
+//	[line 2, col 10] in inner()
+//	[line 6, col 15] in outer()
+//	[line 9, col 6] in script
+//
+// Runs of identical lines, as left by deep recursion, are collapsed.
+func printTrace(w io.Writer, rt *interpreter.RuntimeError) {
+	if len(rt.Trace) == 0 {
+		return
+	}
+	var lines []string
+	at := rt.Token
+	for _, frame := range rt.Trace {
+		lines = append(lines, fmt.Sprintf("%s in %s()", traceLocation(at), frame.Function))
+		at = frame.Call
+	}
+	lines = append(lines, traceLocation(at)+" in script")
+
+	for i := 0; i < len(lines); {
+		run := 1
+		for i+run < len(lines) && lines[i+run] == lines[i] {
+			run++
+		}
+		fmt.Fprintln(w, lines[i])
+		if run > 1 {
+			fmt.Fprintf(w, "  ... repeated %d more times\n", run-1)
+		}
+		i += run
+	}
+}
+
+func traceLocation(tok token.Token) string {
+	if tok.Line == 0 {
+		return "[native code]"
+	}
+	return "[" + tokenLocation(tok) + "]"
+}
+
+func (l *Lox) report(file string, line, column int, where, message string) {
+	fmt.Fprintf(l.stderr, "[%s] Error%s: %s\n", location(file, line, column), where, message)
+	l.hadError = true
+}
+
+// location describes where an error happened, naming the file only when a
+// //#line directive supplied one.
+func location(file string, line, column int) string {
+	where := fmt.Sprintf("line %d, col %d", line, column)
+	if file != "" {
+		where += " in " + file
+	}
+	return where
+}
+
+func tokenLocation(tok token.Token) string {
+	return location(tok.File, tok.Line, tok.Column)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMain runs golox in place of the tests when a test starts the test
+// binary as golox, so that tests can check its output and exit status.
+func TestMain(m *testing.M) {
+	if os.Getenv("GOLOX_TEST_MAIN") == "1" {
+		main()
+	}
+	os.Exit(m.Run())
+}
+
+// golox runs golox with args in dir, with no config file, and returns what
+// it wrote and the status it exited with.
+func golox(t *testing.T, dir string, args ...string) (stdout, stderr string, status int) {
+	t.Helper()
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(self, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GOLOX_TEST_MAIN=1",
+		"GOLOX_CONFIG="+filepath.Join(dir, "no-config.toml"))
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err = cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		status = exit.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), errOut.String(), status
+}
+
+// writeFiles creates the files named by the keys of files in a new
+// directory, which it returns.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		status int
+	}{
+		{"success", `print 1;`, 0},
+		{"compile error", `print ;`, 65},
+		{"runtime error", `print nil + 1;`, 70},
+		{"exit", `exit(3);`, 3},
+		{"exit before an error", `exit(0); print nil + 1;`, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"s.lox": test.source})
+			if _, stderr, status := golox(t, dir, "s.lox"); status != test.status {
+				t.Errorf("got status %d, want %d; stderr:\n%s", status, test.status, stderr)
+			}
+		})
+	}
+}
+
+func TestExitFlushesReports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"notes.txt": "hello",
+		"s.lox":     "var notes = readFile(\"notes.txt\");\nexit(3);\n",
+	})
+	_, stderr, status := golox(t, dir, "--allow-fs", "--audit-log=audit.jsonl", "--cost-report", "s.lox")
+	if status != 3 {
+		t.Fatalf("got status %d, want 3; stderr:\n%s", status, stderr)
+	}
+	audit, err := os.ReadFile(filepath.Join(dir, "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"capability":"fs","native":"readFile","args":["notes.txt"],"line":1`; !strings.Contains(string(audit), want) {
+		t.Errorf("audit log %q doesn't record the read", audit)
+	}
+	if !strings.Contains(stderr, "-- cost report:") {
+		t.Errorf("stderr %q has no cost report", stderr)
+	}
+}
+
+func TestTokenizeJSON(t *testing.T) {
+	type jsonToken struct {
+		Type    string `json:"type"`
+		Lexeme  string `json:"lexeme"`
+		Literal any    `json:"literal"`
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+	}
+	tests := []struct {
+		name, source string
+		args         []string
+		want         []jsonToken
+		// raw is text the output must hold as written, as JSON decodes
+		// numbers into float64s.
+		raw    string
+		status int
+	}{
+		{name: "tokens", source: "var s = \"a<b\";\nprint 1.5 <= x;", want: []jsonToken{
+			{"VAR", "var", nil, 1, 1}, {"IDENTIFIER", "s", nil, 1, 5}, {"EQUAL", "=", nil, 1, 7},
+			{"STRING", `"a<b"`, "a<b", 1, 9}, {"SEMICOLON", ";", nil, 1, 14},
+			{"PRINT", "print", nil, 2, 1}, {"NUMBER", "1.5", 1.5, 2, 7}, {"LESS_EQUAL", "<=", nil, 2, 11},
+			{"IDENTIFIER", "x", nil, 2, 14}, {"SEMICOLON", ";", nil, 2, 15}, {"EOF", "", nil, 2, 16},
+		}},
+		{name: "big integers stay exact", source: "123456789012345678901", args: []string{"--dialect=bigint"}, want: []jsonToken{
+			{"NUMBER", "123456789012345678901", 123456789012345678901.0, 1, 1}, {"EOF", "", nil, 1, 22},
+		}, raw: `"literal":123456789012345678901,`},
+		{name: "lexical error", source: "@ 1", status: 65, want: []jsonToken{
+			{"NUMBER", "1", 1.0, 1, 3}, {"EOF", "", nil, 1, 4},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"s.lox": test.source})
+			args := append(append([]string{"--format=json"}, test.args...), "tokenize", "s.lox")
+			stdout, stderr, status := golox(t, dir, args...)
+			if status != test.status {
+				t.Errorf("got status %d, want %d; stderr:\n%s", status, test.status, stderr)
+			}
+			var got []jsonToken
+			if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+				t.Fatalf("%v in output:\n%s", err, stdout)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v,\nwant %v", got, test.want)
+			}
+			if !strings.Contains(stdout, test.raw) {
+				t.Errorf("output doesn't hold %s:\n%s", test.raw, stdout)
+			}
+		})
+	}
+
+	dir := writeFiles(t, map[string]string{"s.lox": "1"})
+	if _, stderr, status := golox(t, dir, "--format=xml", "tokenize", "s.lox"); status != 64 || !strings.Contains(stderr, `unknown format "xml"`) {
+		t.Errorf("got status %d and stderr %q, want 64 and an unknown format", status, stderr)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// replStyle is how the REPL looks: its prompts and the colors of its
+// theme.
+type replStyle struct {
+	// prompt is shown before each line, and continuation before each line
+	// of paste mode. In both, {line} stands for the number of the line
+	// being read, counting from 1, and {error} for "!" when the last thing
+	// run failed and nothing otherwise.
+	prompt       string
+	continuation string
+	theme        replTheme
+}
+
+// replTheme holds the escape sequences that color the prompt and error
+// messages. The zero theme leaves them plain.
+type replTheme struct {
+	prompt, errors string
+}
+
+var replThemes = map[string]replTheme{
+	"none":  {},
+	"dark":  {prompt: "\x1b[1;36m", errors: "\x1b[91m"},
+	"light": {prompt: "\x1b[1;34m", errors: "\x1b[31m"},
+}
+
+// parseTheme looks up the theme named by --theme.
+func parseTheme(name string) (replTheme, error) {
+	theme, ok := replThemes[name]
+	if !ok {
+		names := make([]string, 0, len(replThemes))
+		for name := range replThemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return replTheme{}, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return theme, nil
+}
+
+// expandPrompt fills in the placeholders of a prompt.
+func expandPrompt(prompt string, line int, failed bool) string {
+	marker := ""
+	if failed {
+		marker = "!"
+	}
+	return strings.NewReplacer("{line}", strconv.Itoa(line), "{error}", marker).Replace(prompt)
+}
+
+// colorWriter colors everything written through it. Each write is colored
+// on its own, so that whatever else is written in between stays plain.
+type colorWriter struct {
+	w     io.Writer
+	color string
+}
+
+func (c colorWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(c.w, c.color); err != nil {
+		return 0, err
+	}
+	n, err := c.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	_, err = io.WriteString(c.w, "\x1b[0m")
+	return n, err
+}
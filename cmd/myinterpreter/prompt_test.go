@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+func TestParseTheme(t *testing.T) {
+	tests := []struct {
+		name string
+		want replTheme
+		err  string
+	}{
+		{"none", replTheme{}, ""},
+		{"dark", replTheme{prompt: "\x1b[1;36m", errors: "\x1b[91m"}, ""},
+		{"light", replTheme{prompt: "\x1b[1;34m", errors: "\x1b[31m"}, ""},
+		{"solarized", replTheme{}, `unknown theme "solarized", expected one of dark, light, none`},
+	}
+	for _, test := range tests {
+		got, err := parseTheme(test.name)
+		if got != test.want || errorText(err) != test.err {
+			t.Errorf("%s: got %q and error %q, want %q and error %q", test.name, got, errorText(err), test.want, test.err)
+		}
+	}
+}
+
+func TestExpandPrompt(t *testing.T) {
+	tests := []struct {
+		prompt string
+		line   int
+		failed bool
+		want   string
+	}{
+		{"> ", 1, false, "> "},
+		{"{line}> ", 12, false, "12> "},
+		{"lox{error}> ", 3, true, "lox!> "},
+		{"lox{error}> ", 3, false, "lox> "},
+		{"{line}{line} {other}", 2, true, "22 {other}"},
+	}
+	for _, test := range tests {
+		if got := expandPrompt(test.prompt, test.line, test.failed); got != test.want {
+			t.Errorf("%q at line %d, failed %t: got %q, want %q", test.prompt, test.line, test.failed, got, test.want)
+		}
+	}
+}
+
+func TestColorWriter(t *testing.T) {
+	var b strings.Builder
+	w := colorWriter{&b, "\x1b[31m"}
+	if n, err := w.Write([]byte("oops")); n != 4 || err != nil {
+		t.Errorf("got %d, %v, want 4, nil", n, err)
+	}
+	if want := "\x1b[31moops\x1b[0m"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
+
+func TestREPLPrompts(t *testing.T) {
+	tests := []struct {
+		name, input string
+		style       replStyle
+		want        string
+		status      int
+	}{
+		{name: "plain", input: "print 1;\n", style: replStyle{prompt: "> "}, want: "> 1\n> \n"},
+		{name: "line numbers and failures", input: "print nil + 1;\nprint 2;\n",
+			style: replStyle{prompt: "{line}{error}> "}, want: "1> 2!> 2\n3> \n"},
+		{name: "empty prompt", input: "print 1;\n", style: replStyle{}, want: "1\n\n"},
+		{name: "paste mode", input: ":paste\nvar a = 1;\nprint a + 1;\n:end\n",
+			style: replStyle{prompt: "{line}> ", continuation: "{line}| "},
+			want:  "1> // Paste mode: end with :end on a line of its own, or Ctrl+D.\n2| 3| 4| 2\n5> \n"},
+		{name: "bracketed paste", input: pasteStart + "var a = 3;\nprint a;" + pasteEnd + "\n",
+			style: replStyle{prompt: "> "}, want: "> 3\n> \n"},
+		{name: "exit", input: "print 1;\nexit(4);\nprint 2;\n", style: replStyle{prompt: "> "}, want: "> 1\n> ", status: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			l := NewLox(ModeREPL)
+			l.stdout, l.stderr, l.style = &stdout, &stderr, test.style
+			l.interpreter = interpreter.New(&stdout)
+			status := l.runPrompt(strings.NewReader(test.input))
+			if stdout.String() != test.want || status != test.status {
+				t.Errorf("got %q and status %d, want %q and status %d", stdout.String(), status, test.want, test.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Terminals that support bracketed paste, once it is switched on, wrap
+// pasted text in these sequences, so that it can be told apart from typing.
+const (
+	bracketedPasteOn  = "\x1b[?2004h"
+	bracketedPasteOff = "\x1b[?2004l"
+	pasteStart        = "\x1b[200~"
+	pasteEnd          = "\x1b[201~"
+)
+
+// runPrompt reads and runs one line at a time. Code spanning several lines
+// can be run as one unit: either type :paste, then the code, then :end or
+// Ctrl+D, or paste it into a terminal that supports bracketed paste. It
+// returns the status golox should exit with: 0 at the end of the input, or
+// whatever a script passed to exit.
+func (l *Lox) runPrompt(in io.Reader) int {
+	r := &replReader{reader: bufio.NewReader(in)}
+	if isTerminal(l.stdout) {
+		fmt.Fprint(l.stdout, bracketedPasteOn)
+		defer fmt.Fprint(l.stdout, bracketedPasteOff)
+	}
+	if l.style.theme.errors != "" && isTerminal(l.stderr) {
+		l.stderr = colorWriter{l.stderr, l.style.theme.errors}
+	}
+	failed := false
+	for {
+		l.showPrompt(l.style.prompt, r.lines+1, failed)
+		line, err := r.readLine()
+		if err != nil && line == "" {
+			fmt.Fprintln(l.stdout)
+			return 0
+		}
+		source := line
+		switch {
+		case strings.TrimSpace(line) == ":paste":
+			fmt.Fprintln(l.stdout, "// Paste mode: end with :end on a line of its own, or Ctrl+D.")
+			source = l.readPaste(r, failed)
+		case strings.Contains(line, pasteStart):
+			source = r.readBracketedPaste(line)
+		}
+		l.run(source, "")
+		if l.exited {
+			return l.exitCode
+		}
+		failed = l.hadError || l.hadRuntimeError
+		l.hadError = false
+		l.hadRuntimeError = false
+	}
+}
+
+// showPrompt writes a prompt, in the theme's color when writing to a
+// terminal.
+func (l *Lox) showPrompt(prompt string, line int, failed bool) {
+	prompt = expandPrompt(prompt, line, failed)
+	if prompt == "" {
+		return
+	}
+	if l.style.theme.prompt != "" && isTerminal(l.stdout) {
+		prompt = l.style.theme.prompt + prompt + "\x1b[0m"
+	}
+	fmt.Fprint(l.stdout, prompt)
+}
+
+// replReader reads the REPL's input line by line, counting the lines.
+type replReader struct {
+	reader *bufio.Reader
+	lines  int
+}
+
+// readLine reads a line without its line ending. Unlike a bufio.Scanner, a
+// bufio.Reader doesn't remember reaching the end of its input, so after a
+// Ctrl+D ends paste mode, the REPL can go on reading from a terminal.
+func (r *replReader) readLine() (string, error) {
+	line, err := r.reader.ReadString('\n')
+	if err == nil || line != "" {
+		r.lines++
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), err
+}
+
+// readPaste reads lines up to one holding only :end, or the end of the
+// input, and returns them as one piece of source. failed is whether the
+// last thing run failed, for the continuation prompt.
+func (l *Lox) readPaste(r *replReader, failed bool) string {
+	var lines []string
+	for {
+		l.showPrompt(l.style.continuation, r.lines+1, failed)
+		line, err := r.readLine()
+		if strings.TrimSpace(line) == ":end" {
+			break
+		}
+		if line != "" || err == nil {
+			lines = append(lines, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readBracketedPaste reads the rest of a bracketed paste, whose first line
+// has been read, and returns the pasted text without the brackets.
+func (r *replReader) readBracketedPaste(first string) string {
+	text := first
+	for !strings.Contains(text, pasteEnd) {
+		line, err := r.readLine()
+		text += "\n" + line
+		if err != nil {
+			break
+		}
+	}
+	text = strings.ReplaceAll(text, pasteStart, "")
+	return strings.ReplaceAll(text, pasteEnd, "")
+}
+
+// isTerminal reports whether w writes to a terminal, to which escape
+// sequences can be sent.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/scanner"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// scaffoldClass returns the source of a class with the given fields: an
+// init that stores them, a toString describing the instance, and an
+// equals comparing two instances field by field.
+//
+//	class Point {
+//	  init(x, y) {
+//	    this.x = x;
+//	    this.y = y;
+//	  }
+//
+//	  toString() {
+//	    return "Point(x: ${this.x}, y: ${this.y})";
+//	  }
+//
+//	  equals(other) {
+//	    return this.x == other.x and this.y == other.y;
+//	  }
+//	}
+func scaffoldClass(name string, fields []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "class %s {\n", name)
+
+	fmt.Fprintf(&b, "  init(%s) {\n", strings.Join(fields, ", "))
+	for _, field := range fields {
+		fmt.Fprintf(&b, "    this.%s = %s;\n", field, field)
+	}
+	b.WriteString("  }\n\n")
+
+	described := make([]string, len(fields))
+	for n, field := range fields {
+		described[n] = fmt.Sprintf("%s: ${this.%s}", field, field)
+	}
+	b.WriteString("  toString() {\n")
+	fmt.Fprintf(&b, "    return \"%s(%s)\";\n", name, strings.Join(described, ", "))
+	b.WriteString("  }\n\n")
+
+	compared := make([]string, len(fields))
+	for n, field := range fields {
+		compared[n] = fmt.Sprintf("this.%s == other.%s", field, field)
+	}
+	if len(compared) == 0 {
+		compared = []string{"true"}
+	}
+	b.WriteString("  equals(other) {\n")
+	fmt.Fprintf(&b, "    return %s;\n", strings.Join(compared, " and "))
+	b.WriteString("  }\n")
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// checkScaffoldNames reports why the class and field names can't be used,
+// if they can't.
+func checkScaffoldNames(name string, fields []string) error {
+	for _, ident := range append([]string{name}, fields...) {
+		tokens := scanner.New(ident, func(string, int, int, string) {}, 0).ScanTokens()
+		if len(tokens) != 2 || tokens[0].Type != token.Identifier || tokens[0].Lexeme != ident {
+			return fmt.Errorf("%q is not a valid name", ident)
+		}
+	}
+	for n, field := range fields {
+		switch {
+		case field == "init", field == "toString", field == "equals":
+			return fmt.Errorf("a field can't be named %q, which the class uses for a method", field)
+		case field == "other":
+			return errors.New(`a field can't be named "other", which equals uses for its parameter`)
+		case slices.Contains(fields[:n], field):
+			return fmt.Errorf("field %q is listed twice", field)
+		}
+	}
+	return nil
+}
+
+// runScaffold writes the class scaffoldClass generates to NAME.lox in the
+// current directory, refusing to overwrite a file that is already there.
+func (l *Lox) runScaffold(name string, fields []string) {
+	if err := checkScaffoldNames(name, fields); err != nil {
+		fmt.Fprintf(l.stderr, "golox: %v\n", err)
+		os.Exit(64)
+	}
+	path := name + ".lox"
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err == nil {
+		_, err = f.WriteString(scaffoldClass(name, fields))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(l.stderr, "Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(l.stdout, "Wrote %s.\n", path)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScaffoldNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		err    string
+	}{
+		{"Point", []string{"x", "y"}, ""},
+		{"Empty", nil, ""},
+		{"2D", nil, `"2D" is not a valid name`},
+		{"Point", []string{"x y"}, `"x y" is not a valid name`},
+		{"Point", []string{"class"}, `"class" is not a valid name`},
+		{"Point", []string{"init"}, `a field can't be named "init", which the class uses for a method`},
+		{"Point", []string{"equals"}, `a field can't be named "equals", which the class uses for a method`},
+		{"Point", []string{"other"}, `a field can't be named "other", which equals uses for its parameter`},
+		{"Point", []string{"x", "y", "x"}, `field "x" is listed twice`},
+	}
+	for _, test := range tests {
+		err := checkScaffoldNames(test.name, test.fields)
+		if got := errorText(err); got != test.err {
+			t.Errorf("%s %v: got error %q, want %q", test.name, test.fields, got, test.err)
+		}
+	}
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestScaffoldCommand(t *testing.T) {
+	dir := t.TempDir()
+	stdout, stderr, status := golox(t, dir, "scaffold", "class", "Point", "x", "y")
+	if status != 0 || stdout != "Wrote Point.lox.\n" {
+		t.Fatalf("got %q and status %d; stderr:\n%s", stdout, status, stderr)
+	}
+
+	// The class works as written.
+	path := filepath.Join(dir, "Point.lox")
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	use := "\nvar p = Point(1, 2);\nprint p.toString();\nprint p.equals(Point(1, 2));\nprint p.equals(Point(1, 3));\n"
+	if err := os.WriteFile(filepath.Join(dir, "use.lox"), append(source, use...), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if stdout, stderr, status := golox(t, dir, "use.lox"); status != 0 || stdout != "Point(x: 1, y: 2)\ntrue\nfalse\n" {
+		t.Errorf("running the class: got %q and status %d; stderr:\n%s", stdout, status, stderr)
+	}
+
+	// A second run leaves the file alone.
+	if _, stderr, status := golox(t, dir, "scaffold", "class", "Point", "z"); status != 1 || !strings.HasPrefix(stderr, "Error writing file:") {
+		t.Errorf("overwriting: got status %d and stderr %q, want 1 and an error", status, stderr)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(source) {
+		t.Errorf("the file was overwritten:\n%s", again)
+	}
+
+	if _, stderr, status := golox(t, dir, "scaffold", "class", "Bad", "x", "x"); status != 64 || stderr != "golox: field \"x\" is listed twice\n" {
+		t.Errorf("bad fields: got status %d and stderr %q, want 64 and an error", status, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Bad.lox")); err == nil {
+		t.Error("a class with bad fields was written")
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// minFingerprintSize is the smallest subtree, in nodes, that counts towards
+// similarity. Smaller subtrees such as "x + 1" occur in almost every
+// program and would only add noise.
+const minFingerprintSize = 3
+
+// astFingerprint summarizes a syntax tree for similarity detection. Root
+// identifies the whole program and Subtrees counts the hash of every
+// subtree of at least minFingerprintSize nodes.
+//
+// Hashes are normalized: identifiers are ignored entirely, and layout and
+// comments never reach the tree, so consistently renaming variables or
+// reformatting a file does not change its fingerprint.
+type astFingerprint struct {
+	Root     uint64
+	Subtrees map[uint64]int
+}
+
+func fingerprintAST(tree any) astFingerprint {
+	fp := astFingerprint{Subtrees: map[uint64]int{}}
+	fp.Root, _ = fp.hash(reflect.ValueOf(tree))
+	return fp
+}
+
+// hash returns the normalized hash of a subtree and its size in nodes.
+func (fp astFingerprint) hash(v reflect.Value) (uint64, int) {
+	v = unwrap(v)
+	h := fnv.New64a()
+	if !v.IsValid() {
+		return h.Sum64(), 0
+	}
+
+	size := 0
+	switch {
+	case v.Kind() == reflect.Slice:
+		fmt.Fprintf(h, "[%d", v.Len())
+		for i := 0; i < v.Len(); i++ {
+			child, childSize := fp.hash(v.Index(i))
+			fmt.Fprintf(h, ",%x", child)
+			size += childSize
+		}
+		return h.Sum64(), size
+
+	case v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Struct:
+		size = 1
+		fmt.Fprintf(h, "%s(", v.Elem().Type().Name())
+		for i := 0; i < v.Elem().NumField(); i++ {
+			field := v.Elem().Type().Field(i)
+			value := v.Elem().Field(i)
+			switch {
+			case field.Type == tokenType:
+				tok := value.Interface().(token.Token)
+				if tok.Type == token.Identifier {
+					fmt.Fprint(h, "id;")
+				} else {
+					fmt.Fprintf(h, "%s;", spelling(tok))
+				}
+			case field.Type.Kind() == reflect.Interface && field.Type.NumMethod() == 0:
+				fmt.Fprintf(h, "%T:%s;", value.Interface(), describeLiteral(value.Interface()))
+			case field.Type.Kind() == reflect.Interface, field.Type.Kind() == reflect.Pointer, field.Type.Kind() == reflect.Slice:
+				child, childSize := fp.hash(value)
+				fmt.Fprintf(h, "%x;", child)
+				size += childSize
+			}
+		}
+		fmt.Fprint(h, ")")
+		sum := h.Sum64()
+		if size >= minFingerprintSize {
+			fp.Subtrees[sum]++
+		}
+		return sum, size
+	}
+	return h.Sum64(), 0
+}
+
+// similarity is the Jaccard index of the two subtree multisets: 1 for
+// structurally identical programs, 0 when no sizeable subtree is shared.
+func (fp astFingerprint) similarity(other astFingerprint) float64 {
+	shared, total := 0, 0
+	for sum, count := range fp.Subtrees {
+		shared += min(count, other.Subtrees[sum])
+		total += max(count, other.Subtrees[sum])
+	}
+	for sum, count := range other.Subtrees {
+		if _, ok := fp.Subtrees[sum]; !ok {
+			total += count
+		}
+	}
+	if total == 0 {
+		if fp.Root == other.Root {
+			return 1
+		}
+		return 0
+	}
+	return float64(shared) / float64(total)
+}
+
+// runSimilarity fingerprints every .lox file under dir, prints each file's
+// fingerprint, and then every pair of files from most to least similar.
+// Files that do not parse are reported and left out.
+func (l *Lox) runSimilarity(dir string) {
+	paths := l.loxFiles(dir)
+
+	var names []string
+	var prints []astFingerprint
+	for _, path := range paths {
+		l.hadError = false
+		program := l.parseFile(path)
+		if l.hadError {
+			fmt.Fprintf(l.stderr, "Skipping %s: it has syntax errors.\n", path)
+			continue
+		}
+		fp := fingerprintAST(program)
+		fmt.Fprintf(l.stdout, "%016x  %s\n", fp.Root, path)
+		names = append(names, path)
+		prints = append(prints, fp)
+	}
+
+	type pair struct {
+		a, b  string
+		score float64
+	}
+	var pairs []pair
+	for i := range prints {
+		for j := i + 1; j < len(prints); j++ {
+			pairs = append(pairs, pair{names[i], names[j], prints[i].similarity(prints[j])})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].score > pairs[j].score })
+
+	if len(pairs) > 0 {
+		fmt.Fprintln(l.stdout)
+	}
+	for _, p := range pairs {
+		fmt.Fprintf(l.stdout, "%5.1f%%  %s  %s\n", p.score*100, p.a, p.b)
+	}
+}
+
+// loxFiles returns the .lox files under dir, or dir itself if it is a
+// file.
+func (l *Lox) loxFiles(dir string) []string {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && (path == dir || strings.HasSuffix(path, ".lox")) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(l.stderr, "Error reading directory: %v\n", err)
+		os.Exit(1)
+	}
+	return paths
+}
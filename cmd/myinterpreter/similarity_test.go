@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/parser"
+	"github.com/kriyanshii/interpreter-go/scanner"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// parseProgram parses source, failing the test on a syntax error.
+func parseProgram(t *testing.T, source string) []ast.Stmt {
+	t.Helper()
+	tokens := scanner.New(source, func(file string, line, column int, message string) {
+		t.Fatalf("line %d: %s", line, message)
+	}, 0).ScanTokens()
+	return parser.New(tokens, func(tok token.Token, message string) {
+		t.Fatalf("line %d at '%s': %s", tok.Line, tok.Lexeme, message)
+	}).Parse()
+}
+
+func TestFingerprint(t *testing.T) {
+	const program = `
+		fun total(items) {
+			var sum = 0;
+			for (var i = 0; i < items.length; i = i + 1) sum = sum + items.get(i);
+			return sum;
+		}
+		print total(list(1, 2, 3));`
+	tests := []struct {
+		name, other string
+		sameRoot    bool
+		min, max    float64
+	}{
+		{"identical", program, true, 1, 1},
+		{"renamed and reformatted", `
+			fun add_up(xs) { var acc = 0;   // running total
+				for (var k = 0; k < xs.length; k = k + 1)
+					acc = acc + xs.get(k);
+				return acc; }
+			print add_up(list(1, 2, 3));`, true, 1, 1},
+		{"a literal changed", strings.Replace(program, "var sum = 0", "var sum = 1", 1), false, 0.5, 0.99},
+		{"a statement added", program + "\nprint total(list());", false, 0.5, 0.99},
+		{"unrelated", `class Point { init(x, y) { this.x = x; this.y = y; } }`, false, 0, 0},
+	}
+	base := fingerprintAST(parseProgram(t, program))
+	for _, test := range tests {
+		other := fingerprintAST(parseProgram(t, test.other))
+		if (base.Root == other.Root) != test.sameRoot {
+			t.Errorf("%s: got same root %t, want %t", test.name, base.Root == other.Root, test.sameRoot)
+		}
+		for _, score := range []float64{base.similarity(other), other.similarity(base)} {
+			if score < test.min || score > test.max {
+				t.Errorf("%s: got similarity %.3f, want %.2f to %.2f", test.name, score, test.min, test.max)
+			}
+		}
+	}
+
+	empty := fingerprintAST(parseProgram(t, ""))
+	if got := empty.similarity(fingerprintAST(parseProgram(t, "// nothing"))); got != 1 {
+		t.Errorf("got similarity %.3f for two empty programs, want 1", got)
+	}
+	if got := empty.similarity(base); got != 0 {
+		t.Errorf("got similarity %.3f for an empty program, want 0", got)
+	}
+}
+
+func TestSimilarityCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.lox":     "var x = 1; print x + 2 * 3;",
+		"b.lox":     "var y = 1; print y + 2 * 3;",
+		"c.lox":     "class C { m() { return this; } }",
+		"bad.lox":   "print ;",
+		"notes.txt": "print 1;",
+	})
+	stdout, stderr, status := golox(t, dir, "similarity", ".")
+	if status != 0 {
+		t.Fatalf("got status %d; stderr:\n%s", status, stderr)
+	}
+	if !strings.Contains(stderr, "Skipping bad.lox: it has syntax errors.") {
+		t.Errorf("stderr %q doesn't skip bad.lox", stderr)
+	}
+	files, pairs, _ := strings.Cut(stdout, "\n\n")
+	lines := strings.Split(files, "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "  a.lox") || !strings.HasSuffix(lines[2], "  c.lox") ||
+		lines[0][:16] != lines[1][:16] || lines[0][:16] == lines[2][:16] {
+		t.Errorf("got fingerprints:\n%s\nwant a.lox and b.lox to share one and c.lox to differ", files)
+	}
+	if want := "100.0%  a.lox  b.lox\n  0.0%  a.lox  c.lox\n  0.0%  b.lox  c.lox\n"; pairs != want {
+		t.Errorf("got pairs:\n%s\nwant:\n%s", pairs, want)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// printSnippet shows the source line an error was found on, underlining
+// the offending text the way compilers such as clang and rustc do:
+//
+//	3 | var x = 1 + ;
+//	  |             ^
+//
+// lexeme is the text of the token at fault, or empty for lexical errors,
+// which are underlined with a single caret. When the line can't be found,
+// or no longer holds the token, e.g. for an error inside a function that
+// an earlier REPL line defined, nothing is printed.
+func (l *Lox) printSnippet(file string, line, column int, lexeme string) {
+	lines := l.sourceLines(file)
+	if line < 1 || line > len(lines) || column < 1 {
+		return
+	}
+	text := strings.TrimSuffix(lines[line-1], "\r")
+	prefixLen := 0
+	for i := 1; i < column; i++ {
+		if prefixLen >= len(text) {
+			return
+		}
+		_, size := utf8.DecodeRuneInString(text[prefixLen:])
+		prefixLen += size
+	}
+
+	length := 1
+	if lexeme != "" {
+		first, _, _ := strings.Cut(lexeme, "\n")
+		if !strings.HasPrefix(text[prefixLen:], first) {
+			return
+		}
+		length = max(utf8.RuneCountInString(first), 1)
+	}
+
+	// Keep tabs in the padding so the caret lines up however wide they are.
+	padding := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return ' '
+	}, text[:prefixLen])
+
+	gutter := len(fmt.Sprint(line))
+	fmt.Fprintf(l.stderr, "%*d | %s\n", gutter+1, line, text)
+	fmt.Fprintf(l.stderr, "%*s | %s^%s\n", gutter+1, "", padding, strings.Repeat("~", length-1))
+}
+
+// sourceLines returns the lines of the named file. The empty name stands
+// for the source being run; other files, named by //#include or //#line
+// directives, are read when first needed.
+func (l *Lox) sourceLines(file string) []string {
+	if file == "" {
+		return strings.Split(l.source, "\n")
+	}
+	if lines, ok := l.files[file]; ok {
+		return lines
+	}
+	var lines []string
+	if source, err := os.ReadFile(file); err == nil {
+		lines = strings.Split(string(source), "\n")
+	}
+	if l.files == nil {
+		l.files = map[string][]string{}
+	}
+	l.files[file] = lines
+	return lines
+}
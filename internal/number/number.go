@@ -0,0 +1,113 @@
+// Package number formats and parses Lox numbers.
+package number
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Number formatting and parsing are spelled out here rather than left to
+// fmt so that output never depends on the host's locale or on formatting
+// defaults, and so that every number printed by Lox scans back to exactly
+// the same float64.
+
+// Format renders a number the way print shows it: plain decimal
+// notation with no exponent, no trailing zeros and no trailing ".", using
+// the fewest significant digits that identify the value uniquely.
+func Format(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+
+	var b strings.Builder
+	if math.Signbit(v) {
+		b.WriteByte('-')
+	}
+	digits, point := shortestDigits(math.Abs(v))
+	switch {
+	case point <= 0:
+		b.WriteString("0.")
+		b.WriteString(strings.Repeat("0", -point))
+		b.WriteString(digits)
+	case point >= len(digits):
+		b.WriteString(digits)
+		b.WriteString(strings.Repeat("0", point-len(digits)))
+	default:
+		b.WriteString(digits[:point])
+		b.WriteByte('.')
+		b.WriteString(digits[point:])
+	}
+	return b.String()
+}
+
+// FormatLiteral renders a number the way the tokenize command shows
+// literals: like Format, but integers keep a ".0".
+func FormatLiteral(v float64) string {
+	s := Format(v)
+	if !strings.ContainsAny(s, ".IN") {
+		s += ".0"
+	}
+	return s
+}
+
+// shortestDigits returns the shortest decimal digit string d1d2...dn that
+// round-trips to v, together with the position of the decimal point, so
+// that v = 0.d1d2...dn × 10^point. v must be finite and non-negative.
+func shortestDigits(v float64) (digits string, point int) {
+	if v == 0 {
+		return "0", 1
+	}
+	// The 'e' format with precision -1 yields the shortest round-tripping
+	// mantissa as "d.ddde±xx"; only its digits and exponent are used.
+	s := strconv.FormatFloat(v, 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(s, "e")
+	exp, _ := strconv.Atoi(exponent)
+	return strings.Replace(mantissa, ".", "", 1), exp + 1
+}
+
+// MaxExactInt is the magnitude below which every integer is exactly
+// representable as a float64.
+const MaxExactInt = 1 << 53
+
+var errMalformed = errors.New("malformed number")
+
+// ParseDecimal parses the text of a decimal number literal: ASCII digits
+// with at most one '.' between digits, optionally followed by an exponent,
+// an 'e' or 'E' then digits with an optional sign. Anything else, such as
+// a locale's "1,5" or a sign on the number itself, is rejected rather than
+// interpreted. Like strconv.ParseFloat, it returns ±Inf and an error
+// wrapping strconv.ErrRange for a number too large for a float64.
+func ParseDecimal(text string) (float64, error) {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(text), "e")
+	if hasExponent {
+		if exponent != "" && (exponent[0] == '+' || exponent[0] == '-') {
+			exponent = exponent[1:]
+		}
+		if exponent == "" || strings.Trim(exponent, "0123456789") != "" {
+			return 0, errMalformed
+		}
+	}
+	seenPoint := false
+	for i := 0; i < len(mantissa); i++ {
+		switch c := mantissa[i]; {
+		case '0' <= c && c <= '9':
+		case c == '.' && !seenPoint && i > 0 && i < len(mantissa)-1:
+			seenPoint = true
+		default:
+			return 0, errMalformed
+		}
+	}
+	if mantissa == "" {
+		return 0, errMalformed
+	}
+	// ParseFloat rounds correctly and is locale-independent; the checks
+	// above guarantee it only ever sees the plain decimal form.
+	return strconv.ParseFloat(text, 64)
+}
@@ -0,0 +1,74 @@
+package number
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tenth := 0.1 // A variable, so that 0.1 + 0.2 is not folded exactly.
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0"},
+		{math.Copysign(0, -1), "-0"},
+		{1, "1"},
+		{-42, "-42"},
+		{1.5, "1.5"},
+		{0.1, "0.1"},
+		{tenth + 0.2, "0.30000000000000004"},
+		{1.25e-7, "0.000000125"},
+		{123456.789, "123456.789"},
+		{1e21, "1000000000000000000000"},
+		{1.2345678901234568e20, "123456789012345680000"},
+		{math.MaxFloat64, "17976931348623157" + strings.Repeat("0", 292)},
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "Infinity"},
+		{math.Inf(-1), "-Infinity"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.in); got != tt.want {
+			t.Errorf("Format(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatLiteral(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{42, "42.0"},
+		{0, "0.0"},
+		{1.23, "1.23"},
+		{1e21, "1000000000000000000000.0"},
+	}
+	for _, tt := range tests {
+		if got := FormatLiteral(tt.in); got != tt.want {
+			t.Errorf("FormatLiteral(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	for _, text := range []string{"", "1,5", "1.", ".5", "1.2.3", "+1", "-1", "١٢", "e3", "1e", "1e+", "1e+-3", "1.e3", "1e3.5", "1e3e3"} {
+		if _, err := ParseDecimal(text); err == nil {
+			t.Errorf("ParseDecimal(%q) succeeded, want error", text)
+		}
+	}
+	if got, err := ParseDecimal("0012.50"); err != nil || got != 12.5 {
+		t.Errorf("ParseDecimal(%q) = %v, %v; want 12.5", "0012.50", got, err)
+	}
+	for text, want := range map[string]float64{"1e3": 1000, "1.5E3": 1500, "25e-1": 2.5, "1e+2": 100, "1e-400": 0} {
+		if got, err := ParseDecimal(text); err != nil || got != want {
+			t.Errorf("ParseDecimal(%q) = %v, %v; want %v", text, got, err, want)
+		}
+	}
+	if got, err := ParseDecimal("1e400"); !errors.Is(err, strconv.ErrRange) || !math.IsInf(got, 1) {
+		t.Errorf("ParseDecimal(%q) = %v, %v; want +Inf and a range error", "1e400", got, err)
+	}
+}
@@ -0,0 +1,101 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// AuditLog records every call a script makes to a native that reaches
+// outside the interpreter, such as readFile or tcpConnect, so that a host
+// running untrusted code can review what it did. Each call is written as
+// one line of JSON:
+//
+//	{"time":"2024-05-01T12:00:00.000Z","capability":"fs","native":"readFile",
+//	 "args":["notes.txt"],"line":3,"column":17}
+//
+// line and column locate the call in the script, and file names the file
+// when a directive attributed the line to another one. Calls that fail
+// also carry an "error". Long strings among the arguments are cut short.
+// An AuditLog can be shared by interpreters on several goroutines.
+type AuditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewAuditLog returns an audit log that writes to w.
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w}
+}
+
+type auditRecord struct {
+	Time       string `json:"time"`
+	Capability string `json:"capability"`
+	Native     string `json:"native"`
+	Args       []any  `json:"args"`
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Error      string `json:"error,omitempty"`
+}
+
+// maxAuditString is how much of a string argument, such as the text given
+// to writeFile, the log keeps.
+const maxAuditString = 256
+
+func (l *AuditLog) record(site token.Token, native *nativeFunction, arguments []any, err error) {
+	record := auditRecord{
+		Time:       time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+		Capability: native.capability,
+		Native:     native.name,
+		Args:       make([]any, len(arguments)),
+		File:       site.File,
+		Line:       site.Line,
+		Column:     site.Column,
+	}
+	for n, argument := range arguments {
+		record.Args[n] = auditValue(argument)
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	line, _ := json.Marshal(record)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// The log is best effort: a script's calls shouldn't fail because the
+	// log can't be written.
+	_, _ = l.w.Write(append(line, '\n'))
+}
+
+// auditValue returns the form an argument takes in the log: JSON's own
+// for strings, booleans, nil and finite numbers, and as Lox prints it
+// otherwise.
+func auditValue(value any) any {
+	switch v := value.(type) {
+	case nil, bool:
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return Stringify(v)
+		}
+		return v
+	case string:
+		if len(v) > maxAuditString {
+			// Cut at the start of a character, so as not to split one.
+			cut := maxAuditString
+			for cut > 0 && !utf8.RuneStart(v[cut]) {
+				cut--
+			}
+			return fmt.Sprintf("%s... (%d bytes)", v[:cut], len(v))
+		}
+		return v
+	}
+	return Stringify(value)
+}
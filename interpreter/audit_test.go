@@ -0,0 +1,46 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	// After the "x", each 'é' takes two bytes, so the limit falls inside
+	// one of them.
+	text := "x" + strings.Repeat("é", maxAuditString)
+	i, run := prepare(t, `
+		var path = joinPath(`+strconv.Quote(dir)+`, "out.txt");
+		print basename(path) + dirname(path);
+		writeFile(path, `+strconv.Quote(text)+`);`)
+	var log bytes.Buffer
+	i.Audit = NewAuditLog(&log)
+	i.EnableFileSystem()
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d records, want only writeFile's:\n%s", len(lines), log.String())
+	}
+	var record auditRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Native != "writeFile" || record.Args[0] != path {
+		t.Errorf("got %+v", record)
+	}
+	logged := record.Args[1].(string)
+	kept := strings.TrimSuffix(logged, "... ("+strconv.Itoa(len(text))+" bytes)")
+	if kept == logged || !utf8.ValidString(kept) || !strings.HasPrefix(text, kept) || len(kept) != maxAuditString-1 {
+		t.Errorf("logged %q, want the text cut at a character boundary", logged)
+	}
+}
@@ -0,0 +1,111 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/kriyanshii/interpreter-go/internal/number"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// With BigInt set, a Lox number is either a float64 or, for
+// integers too large for a float64 to hold exactly, a *big.Int. Results are
+// normalized so a value is only ever a *big.Int when it has to be.
+
+// normalizeBig returns n as a float64 when that loses nothing.
+func normalizeBig(n *big.Int) any {
+	if n.IsInt64() {
+		if v := n.Int64(); v >= -number.MaxExactInt && v <= number.MaxExactInt {
+			return float64(v)
+		}
+	}
+	return n
+}
+
+// toBigInt returns v as an integer if it is one.
+func toBigInt(v any) (*big.Int, bool) {
+	switch n := v.(type) {
+	case *big.Int:
+		return n, true
+	case float64:
+		if n != math.Trunc(n) || math.IsInf(n, 0) {
+			return nil, false
+		}
+		b, _ := big.NewFloat(n).Int(nil)
+		return b, true
+	}
+	return nil, false
+}
+
+// toNumber returns v as a float64 if it is a number of either
+// representation. Converting a *big.Int may round.
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+// bigBinary performs an arithmetic or comparison operator exactly when
+// both operands are integers. It reports false when the operands are not
+// both integers, or the operation has no exact integer result, so the
+// caller falls back to float64 arithmetic.
+func bigBinary(operator token.Type, left, right any) (any, bool) {
+	l, lok := toBigInt(left)
+	r, rok := toBigInt(right)
+	if !lok || !rok {
+		return nil, false
+	}
+
+	switch operator {
+	case token.Plus:
+		return normalizeBig(new(big.Int).Add(l, r)), true
+	case token.Minus:
+		return normalizeBig(new(big.Int).Sub(l, r)), true
+	case token.Star:
+		return normalizeBig(new(big.Int).Mul(l, r)), true
+	case token.Slash:
+		if r.Sign() == 0 {
+			return nil, false
+		}
+		quotient, remainder := new(big.Int).QuoRem(l, r, new(big.Int))
+		if remainder.Sign() != 0 {
+			return nil, false
+		}
+		return normalizeBig(quotient), true
+	case token.Percent:
+		if r.Sign() == 0 {
+			return nil, false
+		}
+		remainder := new(big.Int).Rem(l, r)
+		if remainder.Sign() != 0 && remainder.Sign() != r.Sign() {
+			remainder.Add(remainder, r)
+		}
+		return normalizeBig(remainder), true
+	case token.Greater:
+		return l.Cmp(r) > 0, true
+	case token.GreaterEqual:
+		return l.Cmp(r) >= 0, true
+	case token.Less:
+		return l.Cmp(r) < 0, true
+	case token.LessEqual:
+		return l.Cmp(r) <= 0, true
+	}
+	return nil, false
+}
+
+func isBig(v any) bool {
+	_, ok := v.(*big.Int)
+	return ok
+}
+
+// bigEqual compares two values exactly, at least one of them a *big.Int.
+func bigEqual(a, b any) bool {
+	l, lok := toBigInt(a)
+	r, rok := toBigInt(b)
+	return lok && rok && l.Cmp(r) == 0
+}
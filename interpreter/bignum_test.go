@@ -0,0 +1,33 @@
+package interpreter
+
+import "testing"
+
+func TestBigInt(t *testing.T) {
+	bigInt := func(i *Interpreter) { i.BigInt = true }
+	runPrograms(t, []programTest{
+		{name: "literals beyond 2^53", source: `print 9007199254740993; print 123456789012345678901234567890;`,
+			want: "9007199254740993\n123456789012345678901234567890\n", setup: bigInt},
+		{name: "exact arithmetic", source: `
+			print 9007199254740992 + 1;
+			print 9007199254740993 - 2;
+			print 4611686018427387904 * 4;
+			print 18446744073709551616 / 2;
+			print -18446744073709551617 % 10;`,
+			want: "9007199254740993\n9007199254740991\n18446744073709551616\n9223372036854775808\n3\n", setup: bigInt},
+		{name: "results small enough become floats", source: `print 9007199254740993 - 9007199254740992; print (9007199254740993 - 9007199254740992) / 2;`,
+			want: "1\n0.5\n", setup: bigInt},
+		{name: "inexact division falls back to floats", source: `print 18446744073709551617 / 2;`,
+			want: "9223372036854776000\n", setup: bigInt},
+		{name: "fractions fall back to floats", source: `print 9007199254740993 + 0.5;`, want: "9007199254740992\n", setup: bigInt},
+		{name: "comparison and equality", source: `
+			var big = 9007199254740993;
+			print big > 9007199254740992; print big == 9007199254740993; print big == 9007199254740992;
+			print 9007199254740992 * 2 == 18014398509481984; print big == "9007199254740993";`,
+			want: "true\ntrue\nfalse\ntrue\nfalse\n", setup: bigInt},
+		{name: "negation", source: `print -9007199254740993;`, want: "-9007199254740993\n", setup: bigInt},
+		{name: "division by zero", source: `print 9007199254740993 / 0;`, want: "Infinity\n", setup: bigInt},
+		{name: "remainder by zero", source: `print 9007199254740993 % 0;`, err: "Division by zero.", setup: bigInt},
+		{name: "not a number", source: `print 9007199254740993 + "a";`, err: "Operands must be two numbers or two strings.", setup: bigInt},
+		{name: "off by default", source: `print 9007199254740992 + 1;`, want: "9007199254740992\n"},
+	})
+}
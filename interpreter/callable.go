@@ -0,0 +1,155 @@
+package interpreter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// LoxCallable is any value that can be called from Lox: user-defined
+// functions and natives implemented in Go.
+type LoxCallable interface {
+	// Arity is the number of arguments the callable expects, or -1 if it
+	// accepts any number.
+	Arity() int
+	Call(interpreter *Interpreter, arguments []any) (any, error)
+}
+
+// LoxFunction is a user-defined function together with the environment it
+// was declared in, which it closes over.
+type LoxFunction struct {
+	declaration   *ast.FunctionStmt
+	closure       *Environment
+	isInitializer bool
+}
+
+// bind returns a copy of the method whose closure defines "this" as
+// instance.
+func (f *LoxFunction) bind(instance *LoxInstance) *LoxFunction {
+	environment := NewEnvironment(f.closure)
+	environment.define("this", instance)
+	return &LoxFunction{declaration: f.declaration, closure: environment, isInitializer: f.isInitializer}
+}
+
+func (f *LoxFunction) Arity() int {
+	return len(f.declaration.Params)
+}
+
+func (f *LoxFunction) Call(interpreter *Interpreter, arguments []any) (any, error) {
+	environment := NewEnvironment(f.closure)
+	for i, param := range f.declaration.Params {
+		environment.define(param.Lexeme, arguments[i])
+	}
+
+	err := interpreter.executeBlock(f.declaration.Body, environment)
+	if ret, ok := err.(*returnValue); ok {
+		err = nil
+		if !f.isInitializer {
+			return ret.value, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	// An initializer always returns the instance, even from "return;".
+	if f.isInitializer {
+		return f.closure.values["this"], nil
+	}
+	return nil, nil
+}
+
+func (f *LoxFunction) String() string {
+	if f.anonymous() {
+		return "<fn>"
+	}
+	return "<fn " + f.declaration.Name.Lexeme + ">"
+}
+
+// anonymous reports whether f was written as a function expression, which
+// is named by its "fun" keyword or arrow.
+func (f *LoxFunction) anonymous() bool {
+	return f.declaration.Name.Type != token.Identifier
+}
+
+// returnValue carries the value of a return statement up through the
+// statements being executed to the LoxFunction that called them. It is
+// only an error in the sense that it unwinds like one.
+type returnValue struct {
+	value any
+}
+
+func (*returnValue) Error() string { return "Can't return from top-level code." }
+
+// callableName names a callable in stack traces.
+func callableName(callable LoxCallable) string {
+	switch c := callable.(type) {
+	case *LoxFunction:
+		if c.anonymous() {
+			return "<anonymous>"
+		}
+		return c.declaration.Name.Lexeme
+	case *LoxClass:
+		return c.name
+	case *nativeFunction:
+		return c.name
+	}
+	return fmt.Sprint(callable)
+}
+
+// nativeFunction is a LoxCallable implemented in Go.
+type nativeFunction struct {
+	name  string
+	arity int
+	fn    func(interpreter *Interpreter, arguments []any) (any, error)
+	// capability names what the native reaches outside the interpreter,
+	// such as "fs" or "net", if anything. Calls to such natives are
+	// audited.
+	capability string
+}
+
+func (n *nativeFunction) Arity() int {
+	return n.arity
+}
+
+func (n *nativeFunction) Call(interpreter *Interpreter, arguments []any) (any, error) {
+	return n.fn(interpreter, arguments)
+}
+
+func (n *nativeFunction) String() string {
+	return "<native fn>"
+}
+
+// Define binds name to value in the global scope, replacing any existing
+// global of that name.
+func (i *Interpreter) Define(name string, value any) {
+	i.globals.define(name, value)
+}
+
+// DefineNative makes a Go function callable from Lox as the global name.
+// The interpreter checks that it is passed exactly arity arguments, unless
+// arity is -1. An error returned by fn becomes a runtime error at the call.
+func (i *Interpreter) DefineNative(name string, arity int, fn func(interpreter *Interpreter, arguments []any) (any, error)) {
+	i.Define(name, &nativeFunction{name: name, arity: arity, fn: fn})
+}
+
+// defineCapability is DefineNative for a native that reaches outside the
+// interpreter through capability, so that its calls are audited.
+func (i *Interpreter) defineCapability(capability, name string, arity int, fn func(interpreter *Interpreter, arguments []any) (any, error)) {
+	i.Define(name, &nativeFunction{name: name, arity: arity, fn: fn, capability: capability})
+}
+
+// defineNatives installs the built-in functions in the global scope.
+func defineNatives(globals *Environment) {
+	globals.define("clock", &nativeFunction{name: "clock", arity: 0, fn: func(*Interpreter, []any) (any, error) {
+		return float64(time.Now().UnixNano()) / float64(time.Second), nil
+	}})
+	defineEventNatives(globals)
+	defineListNatives(globals)
+	defineMapNatives(globals)
+	defineCloneNatives(globals)
+	defineSerializeNatives(globals)
+	defineParallelNatives(globals)
+	defineExitNatives(globals)
+}
@@ -0,0 +1,204 @@
+package interpreter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+)
+
+// maxCanvasSide bounds canvas dimensions so a typo can't allocate
+// gigabytes.
+const maxCanvasSide = 8192
+
+// EnableGraphics defines the canvas natives, which draw pictures and save
+// them as PNG or SVG files:
+//
+//	canvasNew(width, height)          a white canvas; returns the canvas
+//	setColor(canvas, r, g, b)         the color of later lines, each 0-255
+//	drawLine(canvas, x0, y0, x1, y1)  a line in the current color; (0, 0)
+//	                                  is the top left corner
+//	savePNG(canvas, path)             write the canvas as a PNG image
+//	saveSVG(canvas, path)             write the canvas as an SVG drawing
+//
+// Like the file natives they can write anywhere the process can, so they
+// are left out unless the embedder asks for them.
+func (i *Interpreter) EnableGraphics() {
+	i.DefineNative("canvasNew", 2, func(_ *Interpreter, args []any) (any, error) {
+		width, ok1 := toNumber(args[0])
+		height, ok2 := toNumber(args[1])
+		if !ok1 || !ok2 || width < 1 || height < 1 || width > maxCanvasSide || height > maxCanvasSide {
+			return nil, fmt.Errorf("Canvas size must be between 1 and %d.", maxCanvasSide)
+		}
+		img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		return &canvas{img: img, color: color.RGBA{A: 255}}, nil
+	})
+	i.DefineNative("setColor", 4, func(_ *Interpreter, args []any) (any, error) {
+		c, err := asCanvas(args[0])
+		if err != nil {
+			return nil, err
+		}
+		var rgb [3]uint8
+		for n, arg := range args[1:] {
+			v, ok := toNumber(arg)
+			if !ok || v < 0 || v > 255 {
+				return nil, errors.New("Color components must be numbers from 0 to 255.")
+			}
+			rgb[n] = uint8(math.Round(v))
+		}
+		c.color = color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 255}
+		return nil, nil
+	})
+	i.DefineNative("drawLine", 5, func(_ *Interpreter, args []any) (any, error) {
+		c, err := asCanvas(args[0])
+		if err != nil {
+			return nil, err
+		}
+		var coords [4]float64
+		for n, arg := range args[1:] {
+			v, ok := toNumber(arg)
+			if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, errors.New("Coordinates must be numbers.")
+			}
+			coords[n] = v
+		}
+		c.drawLine(coords[0], coords[1], coords[2], coords[3])
+		return nil, nil
+	})
+	i.defineCapability("fs", "savePNG", 2, func(_ *Interpreter, args []any) (any, error) {
+		c, err := asCanvas(args[0])
+		if err != nil {
+			return nil, err
+		}
+		path, err := pathArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if err := c.save(path, func(w *bufio.Writer) error { return png.Encode(w, c.img) }); err != nil {
+			return nil, fsError("write", path, err)
+		}
+		return nil, nil
+	})
+	i.defineCapability("fs", "saveSVG", 2, func(_ *Interpreter, args []any) (any, error) {
+		c, err := asCanvas(args[0])
+		if err != nil {
+			return nil, err
+		}
+		path, err := pathArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if err := c.save(path, c.writeSVG); err != nil {
+			return nil, fsError("write", path, err)
+		}
+		return nil, nil
+	})
+}
+
+// canvas is the Lox value for a drawing. Lines are both rasterized into
+// img, for PNG output, and kept as segments, for SVG output.
+type canvas struct {
+	img   *image.RGBA
+	color color.RGBA
+	lines []segment
+}
+
+type segment struct {
+	x0, y0, x1, y1 float64
+	color          color.RGBA
+}
+
+func asCanvas(value any) (*canvas, error) {
+	if c, ok := value.(*canvas); ok {
+		return c, nil
+	}
+	return nil, errors.New("Expected a canvas.")
+}
+
+func (c *canvas) String() string {
+	b := c.img.Bounds()
+	return fmt.Sprintf("<canvas %dx%d>", b.Dx(), b.Dy())
+}
+
+// drawLine rasterizes the segment one pixel wide with Bresenham's
+// algorithm, clipping whatever falls outside the canvas.
+func (c *canvas) drawLine(x0, y0, x1, y1 float64) {
+	c.lines = append(c.lines, segment{x0, y0, x1, y1, c.color})
+
+	// Pixels are addressed by the integer coordinates of their top left
+	// corners; endpoints far outside the canvas are clamped first so the
+	// loop below stays short.
+	limit := float64(2 * maxCanvasSide)
+	clamp := func(v float64) int { return int(math.Round(math.Max(-limit, math.Min(limit, v)))) }
+	px0, py0, px1, py1 := clamp(x0), clamp(y0), clamp(x1), clamp(y1)
+
+	dx, dy := abs(px1-px0), -abs(py1-py0)
+	sx, sy := 1, 1
+	if px0 > px1 {
+		sx = -1
+	}
+	if py0 > py1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		if image.Pt(px0, py0).In(c.img.Bounds()) {
+			c.img.SetRGBA(px0, py0, c.color)
+		}
+		if px0 == px1 && py0 == py1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			px0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			py0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (c *canvas) writeSVG(w *bufio.Writer) error {
+	b := c.img.Bounds()
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\">\n", b.Dx(), b.Dy())
+	fmt.Fprintf(w, "  <rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+	for _, l := range c.lines {
+		fmt.Fprintf(w, "  <line x1=\"%g\" y1=\"%g\" x2=\"%g\" y2=\"%g\" stroke=\"#%02x%02x%02x\" stroke-linecap=\"square\"/>\n",
+			l.x0, l.y0, l.x1, l.y1, l.color.R, l.color.G, l.color.B)
+	}
+	_, err := fmt.Fprintln(w, "</svg>")
+	return err
+}
+
+// save creates path and writes the canvas to it with encode.
+func (c *canvas) save(path string, encode func(*bufio.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := encode(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
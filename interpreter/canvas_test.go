@@ -0,0 +1,82 @@
+package interpreter
+
+import (
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCanvasErrors(t *testing.T) {
+	graphics := func(i *Interpreter) { i.EnableGraphics() }
+	runPrograms(t, []programTest{
+		{name: "print", source: `print canvasNew(3, 2);`, want: "<canvas 3x2>\n", setup: graphics},
+		{name: "zero size", source: `canvasNew(0, 10);`, err: "Canvas size must be between 1 and " + strconv.Itoa(maxCanvasSide) + ".", setup: graphics},
+		{name: "too big", source: `canvasNew(10, 100000);`, err: "Canvas size must be between 1 and " + strconv.Itoa(maxCanvasSide) + ".", setup: graphics},
+		{name: "size not a number", source: `canvasNew("10", 10);`, err: "Canvas size must be between 1 and " + strconv.Itoa(maxCanvasSide) + ".", setup: graphics},
+		{name: "not a canvas", source: `setColor(1, 0, 0, 0);`, err: "Expected a canvas.", setup: graphics},
+		{name: "color out of range", source: `setColor(canvasNew(1, 1), 0, 256, 0);`, err: "Color components must be numbers from 0 to 255.", setup: graphics},
+		{name: "color not a number", source: `setColor(canvasNew(1, 1), nil, 0, 0);`, err: "Color components must be numbers from 0 to 255.", setup: graphics},
+		{name: "coordinate not a number", source: `drawLine(canvasNew(1, 1), 0, 0, "1", 0);`, err: "Coordinates must be numbers.", setup: graphics},
+		{name: "infinite coordinate", source: `drawLine(canvasNew(1, 1), 0, 0, 1/0, 0);`, err: "Coordinates must be numbers.", setup: graphics},
+		{name: "save to a missing directory", source: `savePNG(canvasNew(1, 1), "/nonexistent/a.png");`, err: "Can't write '/nonexistent/a.png': no such file or directory.", setup: graphics},
+		{name: "save path not a string", source: `saveSVG(canvasNew(1, 1), 1);`, err: "Path must be a string.", setup: graphics},
+		{name: "disabled by default", source: `canvasNew(1, 1);`, err: "Undefined variable 'canvasNew'."},
+	})
+}
+
+func TestCanvasSave(t *testing.T) {
+	dir := t.TempDir()
+	pngPath, svgPath := filepath.Join(dir, "out.png"), filepath.Join(dir, "out.svg")
+	i, run := prepare(t, `
+		var c = canvasNew(5, 4);
+		setColor(c, 255, 0, 0);
+		drawLine(c, 0, 0, 4, 0);
+		setColor(c, 0, 0, 254.6);
+		drawLine(c, -10, 3, 100, 3);
+		savePNG(c, `+strconv.Quote(pngPath)+`);
+		saveSVG(c, `+strconv.Quote(svgPath)+`);`)
+	i.EnableGraphics()
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(pngPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	red, blue, white := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}, color.RGBA{255, 255, 255, 255}
+	for _, p := range []struct {
+		x, y int
+		want color.RGBA
+	}{{0, 0, red}, {4, 0, red}, {0, 3, blue}, {4, 3, blue}, {2, 1, white}} {
+		if got := color.RGBAModel.Convert(img.At(p.x, p.y)); got != p.want {
+			t.Errorf("pixel (%d, %d) is %v, want %v", p.x, p.y, got, p.want)
+		}
+	}
+	if b := img.Bounds(); b.Dx() != 5 || b.Dy() != 4 {
+		t.Errorf("got a %dx%d image, want 5x4", b.Dx(), b.Dy())
+	}
+
+	svg, err := os.ReadFile(svgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`width="5" height="4"`,
+		`<line x1="0" y1="0" x2="4" y2="0" stroke="#ff0000"`,
+		`<line x1="-10" y1="3" x2="100" y2="3" stroke="#0000ff"`,
+	} {
+		if !strings.Contains(string(svg), want) {
+			t.Errorf("SVG has no %s:\n%s", want, svg)
+		}
+	}
+}
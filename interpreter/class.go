@@ -0,0 +1,75 @@
+package interpreter
+
+import "github.com/kriyanshii/interpreter-go/token"
+
+// LoxClass is a class value. Calling it constructs an instance and runs
+// its init method, if any.
+type LoxClass struct {
+	name       string
+	superclass *LoxClass
+	// methods holds the methods the class inherits as well as its own,
+	// resolved when the class is declared, since neither can change later.
+	methods map[string]*LoxFunction
+}
+
+// findMethod looks name up on the class and its superclasses.
+func (c *LoxClass) findMethod(name string) *LoxFunction {
+	return c.methods[name]
+}
+
+func (c *LoxClass) Arity() int {
+	if initializer := c.findMethod("init"); initializer != nil {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (c *LoxClass) Call(interpreter *Interpreter, arguments []any) (any, error) {
+	instance := &LoxInstance{class: c, fields: map[string]any{}}
+	if initializer := c.findMethod("init"); initializer != nil {
+		if _, err := initializer.bind(instance).Call(interpreter, arguments); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+func (c *LoxClass) String() string {
+	return c.name
+}
+
+// LoxInstance is an object created by calling a class. Fields are created
+// on first assignment.
+type LoxInstance struct {
+	class  *LoxClass
+	fields map[string]any
+}
+
+// get returns a field, or failing that a method bound to this instance.
+// Fields shadow methods.
+func (in *LoxInstance) get(name token.Token) (any, error) {
+	if value, ok := in.fields[name.Lexeme]; ok {
+		return value, nil
+	}
+	if method := in.class.findMethod(name.Lexeme); method != nil {
+		return method.bind(in), nil
+	}
+	return nil, &RuntimeError{Token: name, Message: "Undefined property '" + name.Lexeme + "'."}
+}
+
+func (in *LoxInstance) set(name token.Token, value any) {
+	in.fields[name.Lexeme] = value
+}
+
+func (in *LoxInstance) String() string {
+	return in.class.name + " instance"
+}
+
+// Object is implemented by values other than Lox instances that scripts
+// can read and assign properties of, such as Go values exposed by an
+// embedder. Errors returned by its methods become runtime errors at the
+// property access.
+type Object interface {
+	Get(name string) (any, error)
+	Set(name string, value any) error
+}
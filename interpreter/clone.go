@@ -0,0 +1,124 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// defineCloneNatives defines clone(value, deep), which copies lists, maps
+// and instances:
+//
+//	clone(value)        a shallow copy: the copy holds the same elements,
+//	                    entries or field values as the original
+//	clone(value, true)  a deep copy: lists, maps and instances inside the
+//	                    value are copied too, each once, so cycles and
+//	                    sharing are reproduced in the copy
+//
+// Other values are returned as they are. Numbers, strings and the like
+// can't be changed, and functions, classes and native objects such as
+// sockets are shared rather than copied.
+//
+// A class can take part by defining cloneHook(original), which is called
+// on each copy of its instances once the copy's fields are filled in, to
+// fix up anything that shouldn't be shared or duplicated.
+func defineCloneNatives(globals *Environment) {
+	globals.define("clone", &nativeFunction{name: "clone", arity: -1, fn: func(i *Interpreter, args []any) (any, error) {
+		if len(args) != 1 && len(args) != 2 {
+			return nil, fmt.Errorf("Expected 1 or 2 arguments but got %d.", len(args))
+		}
+		deep := false
+		if len(args) == 2 {
+			d, ok := args[1].(bool)
+			if !ok {
+				return nil, errors.New("Clone depth must be true or false.")
+			}
+			deep = d
+		}
+		c := &cloner{interpreter: i, deep: deep, copies: map[any]any{}}
+		return c.clone(args[0])
+	}})
+}
+
+// cloner copies one value. copies maps each list, map and instance already
+// copied to its copy, so that a deep copy meets each of them only once.
+type cloner struct {
+	interpreter *Interpreter
+	deep        bool
+	copies      map[any]any
+	// hooks are the cloneHook calls to make once copying is done.
+	hooks []func() error
+}
+
+func (c *cloner) clone(value any) (any, error) {
+	copied, err := c.copy(value)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range c.hooks {
+		if err := hook(); err != nil {
+			return nil, err
+		}
+	}
+	return copied, nil
+}
+
+// copy copies value, and with deep set what it holds. Each copy is
+// recorded before its contents are, so a value that contains itself
+// refers to its own copy.
+func (c *cloner) copy(value any) (any, error) {
+	if copied, ok := c.copies[value]; ok {
+		return copied, nil
+	}
+	switch v := value.(type) {
+	case *LoxList:
+		l := NewList(make([]any, len(v.elements)))
+		c.copies[v] = l
+		for n, element := range v.elements {
+			copied, err := c.element(element)
+			if err != nil {
+				return nil, err
+			}
+			l.elements[n] = copied
+		}
+		return l, nil
+	case *LoxMap:
+		m := NewMap()
+		c.copies[v] = m
+		m.order = append(m.order, v.order...)
+		for k, entry := range v.entries {
+			copied, err := c.element(entry.value)
+			if err != nil {
+				return nil, err
+			}
+			m.entries[k] = mapEntry{entry.key, copied}
+		}
+		return m, nil
+	case *LoxInstance:
+		in := &LoxInstance{class: v.class, fields: make(map[string]any, len(v.fields))}
+		c.copies[v] = in
+		for name, field := range v.fields {
+			copied, err := c.element(field)
+			if err != nil {
+				return nil, err
+			}
+			in.fields[name] = copied
+		}
+		if hook := v.class.findMethod("cloneHook"); hook != nil {
+			c.hooks = append(c.hooks, func() error {
+				_, err := c.interpreter.Call(hook.bind(in), []any{v})
+				return err
+			})
+		}
+		return in, nil
+	}
+	return value, nil
+}
+
+// element copies what a list, map or instance holds: for a deep copy it
+// is copied in turn, otherwise the copy shares it.
+func (c *cloner) element(value any) (any, error) {
+	if !c.deep {
+		return value, nil
+	}
+	return c.copy(value)
+}
@@ -0,0 +1,68 @@
+package interpreter
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "shallow", source: `
+			var inner = list(1);
+			var l = list(inner);
+			var c = clone(l);
+			c.push(2); c.get(0).push(3);
+			print l; print c;`, want: "[[1, 3]]\n[[1, 3], 2]\n"},
+		{name: "deep", source: `
+			var l = list(list(1), map());
+			var c = clone(l, true);
+			c.get(0).push(2); c.get(1).set("k", 1);
+			print l; print c;`, want: "[[1], {}]\n[[1, 2], {k: 1}]\n"},
+		{name: "deep cycle", source: `
+			var l = list(1); l.push(l);
+			var c = clone(l, true);
+			print c; print c.get(1) == c; print c.get(1) == l;`, want: "[1, [...]]\ntrue\nfalse\n"},
+		{name: "deep cycle through a map", source: `
+			var m = map(); var l = list(m); m.set("l", l);
+			var c = clone(m, true);
+			print c; print c.get("l").get(0) == c;`, want: "{l: [{...}]}\ntrue\n"},
+		{name: "deep sharing", source: `
+			var a = list(); var b = list(a, a);
+			var c = clone(b, true);
+			print c.get(0) == c.get(1); print c.get(0) == a;`, want: "true\nfalse\n"},
+		{name: "instances", source: `
+			class Point { init(x) { this.x = x; } }
+			var p = Point(1); var q = clone(p);
+			q.x = 2;
+			print p.x; print q.x; print q;`, want: "1\n2\nPoint instance\n"},
+		{name: "shared values", source: `
+			fun f() {} class A {}
+			print clone(f) == f; print clone(A) == A; print clone("s"); print clone(nil);`, want: "true\ntrue\ns\nnil\n"},
+		{name: "bad depth", source: `clone(list(), 1);`, err: "Clone depth must be true or false."},
+	})
+}
+
+func TestCloneHook(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "fixes up the copy", source: `
+			var next = 0;
+			class Node {
+				init() { this.id = next++; this.children = list(); }
+				cloneHook(original) { this.id = next++; this.from = original.id; }
+			}
+			var root = Node(); root.children.push(Node());
+			var c = clone(root, true);
+			print c.id; print c.from; print c.children.get(0).id; print c.children.get(0).from;
+			print root.id;`, want: "3\n0\n2\n1\n0\n"},
+		{name: "runs once the whole value is copied, innermost first", source: `
+			class Node {
+				cloneHook(original) { print this.next.value; }
+			}
+			var a = Node(); var b = Node();
+			a.value = "a"; b.value = "b"; a.next = b; b.next = a;
+			clone(a, true);`, want: "a\nb\n"},
+		{name: "shallow copies too", source: `
+			class A { cloneHook(original) { print "hook"; } }
+			clone(A());`, want: "hook\n"},
+		{name: "errors fail the clone", source: `
+			class A { cloneHook(original) { return nil + 1; } }
+			clone(A());`, err: "Operands must be two numbers or two strings."},
+	})
+}
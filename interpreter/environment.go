@@ -0,0 +1,62 @@
+package interpreter
+
+import "github.com/kriyanshii/interpreter-go/token"
+
+// Environment maps variable names to values for one lexical scope. Lookups
+// that miss walk outwards through the enclosing scopes.
+type Environment struct {
+	enclosing *Environment
+	values    map[string]any
+}
+
+// NewEnvironment creates a scope nested inside enclosing, which is nil for
+// the global scope.
+func NewEnvironment(enclosing *Environment) *Environment {
+	return &Environment{enclosing: enclosing, values: map[string]any{}}
+}
+
+// define binds name in this scope. Redefining an existing name is allowed
+// and simply replaces its value.
+func (e *Environment) define(name string, value any) {
+	e.values[name] = value
+}
+
+func (e *Environment) get(name token.Token) (any, error) {
+	for env := e; env != nil; env = env.enclosing {
+		if value, ok := env.values[name.Lexeme]; ok {
+			return value, nil
+		}
+	}
+	return nil, &RuntimeError{Token: name, Message: "Undefined variable '" + name.Lexeme + "'."}
+}
+
+// assign updates an existing variable in the innermost scope that declares
+// it. Unlike define it never creates a new variable.
+func (e *Environment) assign(name token.Token, value any) error {
+	for env := e; env != nil; env = env.enclosing {
+		if _, ok := env.values[name.Lexeme]; ok {
+			env.values[name.Lexeme] = value
+			return nil
+		}
+	}
+	return &RuntimeError{Token: name, Message: "Undefined variable '" + name.Lexeme + "'."}
+}
+
+// ancestor returns the scope distance steps out from this one.
+func (e *Environment) ancestor(distance int) *Environment {
+	env := e
+	for i := 0; i < distance; i++ {
+		env = env.enclosing
+	}
+	return env
+}
+
+// getAt reads a variable the resolver located distance scopes out.
+func (e *Environment) getAt(distance int, name string) any {
+	return e.ancestor(distance).values[name]
+}
+
+// assignAt writes a variable the resolver located distance scopes out.
+func (e *Environment) assignAt(distance int, name token.Token, value any) {
+	e.ancestor(distance).values[name.Lexeme] = value
+}
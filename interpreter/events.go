@@ -0,0 +1,205 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// events holds the state behind the event natives: timers waiting for
+// runLoop to fire them, the handlers subscribed to named events, and the
+// callbacks that sources running in the background, such as sockets, have
+// queued for runLoop.
+type events struct {
+	timers      []*timer
+	nextTimerID float64
+	handlers    map[string][]LoxCallable
+
+	inbox   chan delivery
+	sources int // background sources that have not yet finished
+}
+
+// delivery asks runLoop to call callback with argument. A delivery with no
+// callback reports that its source has finished.
+type delivery struct {
+	callback LoxCallable
+	argument any
+}
+
+type timer struct {
+	id       float64
+	due      time.Time
+	interval time.Duration // zero for a one-shot timeout
+	callback LoxCallable
+}
+
+// defineEventNatives installs the event natives:
+//
+//	setTimeout(fn, ms)   call fn once, ms milliseconds from now; returns an id
+//	setInterval(fn, ms)  call fn every ms milliseconds; returns an id
+//	clearTimer(id)       cancel a timeout or interval
+//	on(event, fn)        call fn(data) whenever event is emitted
+//	emit(event, data)    call the handlers of event, in subscription order
+//	runLoop()            fire timers as they come due until none remain
+//
+// Timers only fire inside runLoop, so a script sets up its timers and
+// handlers and then hands control to the loop.
+func defineEventNatives(globals *Environment) {
+	globals.define("setTimeout", &nativeFunction{name: "setTimeout", arity: 2, fn: func(i *Interpreter, args []any) (any, error) {
+		return i.events.schedule(args[0], args[1], false)
+	}})
+	globals.define("setInterval", &nativeFunction{name: "setInterval", arity: 2, fn: func(i *Interpreter, args []any) (any, error) {
+		return i.events.schedule(args[0], args[1], true)
+	}})
+	globals.define("clearTimer", &nativeFunction{name: "clearTimer", arity: 1, fn: func(i *Interpreter, args []any) (any, error) {
+		i.events.clear(args[0])
+		return nil, nil
+	}})
+	globals.define("on", &nativeFunction{name: "on", arity: 2, fn: func(i *Interpreter, args []any) (any, error) {
+		event, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("Event name must be a string.")
+		}
+		handler, ok := args[1].(LoxCallable)
+		if !ok {
+			return nil, errors.New("Event handler must be a function.")
+		}
+		if i.events.handlers == nil {
+			i.events.handlers = map[string][]LoxCallable{}
+		}
+		i.events.handlers[event] = append(i.events.handlers[event], handler)
+		return nil, nil
+	}})
+	globals.define("emit", &nativeFunction{name: "emit", arity: 2, fn: func(i *Interpreter, args []any) (any, error) {
+		event, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("Event name must be a string.")
+		}
+		// Copy, so handlers subscribing during the emit run next time.
+		handlers := append([]LoxCallable(nil), i.events.handlers[event]...)
+		for _, handler := range handlers {
+			if _, err := i.Call(handler, []any{args[1]}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}})
+	globals.define("runLoop", &nativeFunction{name: "runLoop", arity: 0, fn: func(i *Interpreter, _ []any) (any, error) {
+		return nil, i.runLoop()
+	}})
+}
+
+func (e *events) schedule(callback, delay any, repeat bool) (any, error) {
+	fn, ok := callback.(LoxCallable)
+	if !ok {
+		return nil, errors.New("Timer callback must be a function.")
+	}
+	ms, ok := toNumber(delay)
+	if !ok || ms < 0 {
+		return nil, errors.New("Timer delay must be a non-negative number of milliseconds.")
+	}
+	d := time.Duration(ms * float64(time.Millisecond))
+	e.nextTimerID++
+	t := &timer{id: e.nextTimerID, due: time.Now().Add(d), callback: fn}
+	if repeat {
+		// An interval of zero would never let the loop finish.
+		t.interval = max(d, time.Millisecond)
+	}
+	e.timers = append(e.timers, t)
+	return t.id, nil
+}
+
+func (e *events) clear(value any) {
+	id, _ := toNumber(value)
+	for n, t := range e.timers {
+		if t.id == id {
+			e.timers = append(e.timers[:n], e.timers[n+1:]...)
+			return
+		}
+	}
+}
+
+// soonest returns the index of the timer due soonest, the earliest
+// scheduled winning ties, or -1 if there are none.
+func (e *events) soonest() int {
+	if len(e.timers) == 0 {
+		return -1
+	}
+	soonest := 0
+	for n, t := range e.timers {
+		if t.due.Before(e.timers[soonest].due) {
+			soonest = n
+		}
+	}
+	return soonest
+}
+
+// startSource registers a background source and returns the channel it
+// sends its deliveries to. It must send a delivery with no callback when
+// it finishes.
+func (e *events) startSource() chan<- delivery {
+	if e.inbox == nil {
+		e.inbox = make(chan delivery, 64)
+	}
+	e.sources++
+	return e.inbox
+}
+
+// runLoop fires timers as they come due and calls the callbacks queued by
+// background sources, sleeping in between, until no timers or sources
+// remain. Trapped signals are handled as they arrive, but don't keep the
+// loop running. A runtime error in a callback stops the loop.
+func (i *Interpreter) runLoop() error {
+	e := &i.events
+	for len(e.timers) > 0 || e.sources > 0 {
+		var wait *time.Timer
+		var due <-chan time.Time
+		if n := e.soonest(); n >= 0 {
+			wait = time.NewTimer(time.Until(e.timers[n].due))
+			due = wait.C
+		}
+
+		var signals <-chan os.Signal
+		if !i.signals.handling {
+			signals = i.signals.pending
+		}
+
+		var callback LoxCallable
+		var arguments []any
+		var sig os.Signal
+		select {
+		case <-due:
+			n := e.soonest()
+			t := e.timers[n]
+			e.timers = append(e.timers[:n], e.timers[n+1:]...)
+			if t.interval > 0 {
+				// Reschedule first, so the callback can clear its own interval.
+				t.due = t.due.Add(t.interval)
+				e.timers = append(e.timers, t)
+			}
+			callback = t.callback
+		case d := <-e.inbox:
+			if d.callback == nil {
+				e.sources--
+			} else {
+				callback, arguments = d.callback, []any{d.argument}
+			}
+		case sig = <-signals:
+		}
+		if wait != nil {
+			wait.Stop()
+		}
+		if sig != nil {
+			if err := i.handleSignal(sig); err != nil {
+				return err
+			}
+		}
+		if callback == nil {
+			continue
+		}
+		if _, err := i.Call(callback, arguments); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package interpreter
+
+import "testing"
+
+func TestEvents(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "timeouts fire in order of due time", source: `
+			setTimeout(fun () { print "late"; }, 20);
+			setTimeout(fun () { print "early"; }, 0);
+			setTimeout(fun () { print "also early"; }, 0);
+			print "before";
+			runLoop();
+			print "after";`, want: "before\nearly\nalso early\nlate\nafter\n"},
+		{name: "interval until cleared", source: `
+			var n = 0;
+			var id;
+			id = setInterval(fun () { n++; print n; if (n == 3) clearTimer(id); }, 1);
+			runLoop();`, want: "1\n2\n3\n"},
+		{name: "cleared timeout", source: `
+			var id = setTimeout(fun () { print "no"; }, 0);
+			clearTimer(id);
+			clearTimer(id);
+			runLoop();
+			print "done";`, want: "done\n"},
+		{name: "timer ids", source: `print setTimeout(fun () {}, 0); print setInterval(fun () {}, 0) > 1;`, want: "1\ntrue\n"},
+		{name: "timers only fire in the loop", source: `setTimeout(fun () { print "fired"; }, 0); print "end";`, want: "end\n"},
+		{name: "timer scheduled by a timer", source: `
+			setTimeout(fun () { print 1; setTimeout(fun () { print 2; }, 0); }, 0);
+			runLoop();`, want: "1\n2\n"},
+		{name: "empty loop", source: `runLoop(); print "done";`, want: "done\n"},
+		{name: "emit calls handlers in order", source: `
+			on("greet", fun (name) { print "hi " + name; });
+			on("greet", fun (name) { print "bye " + name; });
+			emit("greet", "bob");
+			emit("nobody", 1);`, want: "hi bob\nbye bob\n"},
+		{name: "handlers added during emit run next time", source: `
+			on("e", fun (data) { print "first"; on("e", fun (data) { print "second"; }); });
+			emit("e", nil);
+			print "--";
+			emit("e", nil);`, want: "first\n--\nfirst\nsecond\n"},
+		{name: "error in a timer stops the loop", source: `
+			setTimeout(fun () { print "one"; nil + 1; }, 0);
+			setTimeout(fun () { print "two"; }, 5);
+			runLoop();`, want: "one\n", err: "Operands must be two numbers or two strings."},
+		{name: "error in a handler", source: `on("e", fun (data) { data + 1; }); emit("e", nil);`, err: "Operands must be two numbers or two strings."},
+		{name: "callback not a function", source: `setTimeout(1, 0);`, err: "Timer callback must be a function."},
+		{name: "negative delay", source: `setInterval(fun () {}, -1);`, err: "Timer delay must be a non-negative number of milliseconds."},
+		{name: "delay not a number", source: `setTimeout(fun () {}, "soon");`, err: "Timer delay must be a non-negative number of milliseconds."},
+		{name: "event name not a string", source: `on(1, fun (data) {});`, err: "Event name must be a string."},
+		{name: "emit name not a string", source: `emit(nil, 1);`, err: "Event name must be a string."},
+		{name: "handler not a function", source: `on("e", "f");`, err: "Event handler must be a function."},
+	})
+}
@@ -0,0 +1,37 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ExitError is returned by Interpret and Evaluate when the script called
+// exit. It unwinds the script like a runtime error, but it is not one: the
+// script asked to stop, and Code is the status it asked to stop with. It is
+// up to the host what that means; the interpreter never exits the process
+// itself.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string { return "exit status " + strconv.Itoa(e.Code) }
+
+// defineExitNatives installs exit(code), which ends the script with the
+// status code, an integer from 0 to 255. The code may be left out, for 0.
+func defineExitNatives(globals *Environment) {
+	globals.define("exit", &nativeFunction{name: "exit", arity: -1, fn: func(_ *Interpreter, args []any) (any, error) {
+		if len(args) > 1 {
+			return nil, fmt.Errorf("Expected 0 or 1 arguments but got %d.", len(args))
+		}
+		code := 0.0
+		if len(args) == 1 {
+			n, ok := toNumber(args[0])
+			if !ok || n != float64(int(n)) || n < 0 || n > 255 {
+				return nil, errors.New("Exit code must be an integer from 0 to 255.")
+			}
+			code = n
+		}
+		return nil, &ExitError{Code: int(code)}
+	}})
+}
@@ -0,0 +1,60 @@
+package interpreter
+
+import "github.com/kriyanshii/interpreter-go/ast"
+
+// fold replaces expr with a literal if it is made of literals alone and
+// so evaluates to the same value every time, such as the "60 * 60" in
+// "seconds = minutes * 60 * 60". Subexpressions are folded before the
+// expressions around them, so a constant is folded from the bottom up in
+// one pass.
+//
+// Only expressions inside loops are folded: elsewhere they run once and
+// folding them would cost as much as it saves. An expression whose
+// evaluation fails, such as "1 - nil", is left alone so that the error is
+// still reported when, and if, it runs.
+func (r *Resolver) fold(expr ast.Expr) ast.Expr {
+	if r.loops == 0 {
+		return expr
+	}
+	switch e := expr.(type) {
+	case *ast.GroupingExpr:
+		if isLiteral(e.Expression) {
+			return e.Expression
+		}
+		return expr
+	case *ast.UnaryExpr:
+		if !isLiteral(e.Right) {
+			return expr
+		}
+	case *ast.BinaryExpr:
+		if !isLiteral(e.Left) || !isLiteral(e.Right) {
+			return expr
+		}
+	case *ast.LogicalExpr:
+		if !isLiteral(e.Left) || !isLiteral(e.Right) {
+			return expr
+		}
+	case *ast.ConditionalExpr:
+		if !isLiteral(e.Condition) || !isLiteral(e.ThenBranch) || !isLiteral(e.ElseBranch) {
+			return expr
+		}
+	default:
+		return expr
+	}
+	// Folding is not part of running the program, so keep it out of cost
+	// reports.
+	costs := r.interpreter.LineCosts
+	r.interpreter.LineCosts = nil
+	value, err := expr.Accept(r.interpreter)
+	r.interpreter.LineCosts = costs
+	if err != nil {
+		return expr
+	}
+	file, line := ast.ExprPos(expr)
+	return &ast.LiteralExpr{Value: value, File: file, Line: line}
+}
+
+func isLiteral(expr ast.Expr) bool {
+	_, ok := expr.(*ast.LiteralExpr)
+	return ok
+}
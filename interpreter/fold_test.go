@@ -0,0 +1,122 @@
+package interpreter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/parser"
+	"github.com/kriyanshii/interpreter-go/scanner"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// compile scans, parses and resolves source for i, in the bigint dialect
+// if i has it turned on.
+func compile(t *testing.T, i *Interpreter, source string) []ast.Stmt {
+	t.Helper()
+	var mode scanner.Mode
+	if i.BigInt {
+		mode |= scanner.BigInts
+	}
+	tokens := scanner.New(source, func(file string, line, column int, message string) {
+		t.Fatalf("line %d, col %d: %s", line, column, message)
+	}, mode).ScanTokens()
+	fail := func(tok token.Token, message string) {
+		t.Fatalf("line %d at '%s': %s", tok.Line, tok.Lexeme, message)
+	}
+	statements := parser.New(tokens, fail).Parse()
+	NewResolver(i, fail).Resolve(statements)
+	return statements
+}
+
+// loopPrint finds the expression printed in the body of a loop.
+func loopPrint(stmt ast.Stmt) ast.Expr {
+	switch s := stmt.(type) {
+	case *ast.PrintStmt:
+		return s.Expression
+	case *ast.BlockStmt:
+		for _, inner := range s.Statements {
+			if expr := loopPrint(inner); expr != nil {
+				return expr
+			}
+		}
+	case *ast.WhileStmt:
+		return loopPrint(s.Body)
+	}
+	return nil
+}
+
+// TestFoldKeepsSemantics prints each expression once where it is folded,
+// inside a loop, and once where it is not, and expects the same output.
+func TestFoldKeepsSemantics(t *testing.T) {
+	exprs := []string{
+		`60 * 60 * 24`, `(1 + 2) * 3`, `-(4 - 6)`, `7 % 3`, `1 / 3`, `10 == 10.0`,
+		`!nil`, `1 < 2 and "yes"`, `nil or "default"`, `true ? "a" : "b"`,
+		`"con" + "cat"`, `"a" + ("b" + "c")`,
+		`9007199254740992 + 1`, `4611686018427387904 * 4`, `-9007199254740993`,
+		`1 / 0`, `-1 / 0`, `0 / 0`, `0 / 0 == 0 / 0`, `"n" + "" == "n"`,
+	}
+	for _, bigInt := range []bool{false, true} {
+		for _, expr := range exprs {
+			var plain, folded bytes.Buffer
+			i := New(&plain)
+			i.BigInt = bigInt
+			if err := i.Interpret(compile(t, i, `print `+expr+`;`)); err != nil {
+				t.Fatalf("%s: %v", expr, err)
+			}
+
+			i = New(&folded)
+			i.BigInt = bigInt
+			statements := compile(t, i, `for (var n = 0; n < 1; n = n + 1) print `+expr+`;`)
+			if _, ok := loopPrint(statements[0]).(*ast.LiteralExpr); !ok {
+				t.Errorf("%s (bigint %t) was not folded", expr, bigInt)
+			}
+			if err := i.Interpret(statements); err != nil {
+				t.Fatalf("%s: %v", expr, err)
+			}
+			if plain.String() != folded.String() {
+				t.Errorf("%s (bigint %t): folded to %q, want %q", expr, bigInt, folded.String(), plain.String())
+			}
+		}
+	}
+}
+
+// TestFoldLeavesErrors checks that an expression that fails is left for
+// the interpreter to report when it runs, at its own line, and not at all
+// if it never runs.
+func TestFoldLeavesErrors(t *testing.T) {
+	for _, expr := range []string{`1 - nil`, `"a" + 1`, `1 + "a"`, `-"a"`, `5 % 0`, `(1 < "b") ? 1 : 2`} {
+		var out bytes.Buffer
+		i := New(&out)
+		statements := compile(t, i, "for (var n = 0; n < 2; n = n + 1) {\n  print n;\n  if (n == 1) print "+expr+";\n}")
+		err := i.Interpret(statements)
+		rt, ok := err.(*RuntimeError)
+		if !ok || rt.Token.Line != 3 {
+			t.Errorf("%s: got %v, want a runtime error on line 3", expr, err)
+		}
+		if out.String() != "0\n1\n" {
+			t.Errorf("%s: printed %q before failing, want %q", expr, out.String(), "0\n1\n")
+		}
+	}
+
+	i := New(&bytes.Buffer{})
+	if err := i.Interpret(compile(t, i, `while (false) print 1 - nil;`)); err != nil {
+		t.Errorf("an expression that never runs failed: %v", err)
+	}
+}
+
+func TestFoldCosts(t *testing.T) {
+	i := New(&bytes.Buffer{})
+	i.LineCosts = map[SourceLine]int{}
+	statements := compile(t, i, "for (var n = 0; n < 3; n = n + 1)\n  print 60 * 60 * 24;")
+	if len(i.LineCosts) != 0 {
+		t.Errorf("folding was counted in the cost report: %v", i.LineCosts)
+	}
+	if err := i.Interpret(statements); err != nil {
+		t.Fatal(err)
+	}
+	// Each run of line 2 evaluates just the folded literal.
+	if got := i.LineCosts[SourceLine{Line: 2}]; got != 3 {
+		t.Errorf("got %d evaluations of line 2, want 3", got)
+	}
+}
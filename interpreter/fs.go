@@ -0,0 +1,186 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// EnableFileSystem defines the file system natives, which are left out
+// unless the embedder asks for them:
+//
+//	readFile(path)         the contents of a file, as a string
+//	writeFile(path, text)  create or replace a file
+//	listDir(path)          a list of the names in a directory, sorted
+//	joinPath(parts...)     the parts joined with the OS path separator
+//	basename(path)         the last element of path
+//	dirname(path)          all but the last element of path
+//	mkdir(path)            create a directory and any missing parents
+//	remove(path)           delete a file or an empty directory
+//	stat(path)             an object with size, mtime (seconds since the
+//	                       epoch, like clock) and isDir, or nil if nothing
+//	                       exists at path
+//
+// joinPath, basename and dirname only work on strings and never touch the
+// file system, so unlike the rest their calls are not audited.
+func (i *Interpreter) EnableFileSystem() {
+	i.defineCapability("fs", "readFile", 1, func(_ *Interpreter, args []any) (any, error) {
+		path, err := pathArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fsError("read", path, err)
+		}
+		return string(data), nil
+	})
+	i.defineCapability("fs", "writeFile", 2, func(_ *Interpreter, args []any) (any, error) {
+		path, err := pathArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		text, ok := args[1].(string)
+		if !ok {
+			return nil, errors.New("Can only write strings.")
+		}
+		if err := os.WriteFile(path, []byte(text), 0o666); err != nil {
+			return nil, fsError("write", path, err)
+		}
+		return nil, nil
+	})
+	i.defineCapability("fs", "listDir", 1, func(_ *Interpreter, args []any) (any, error) {
+		path, err := pathArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fsError("list", path, err)
+		}
+		names := make([]any, len(entries))
+		for n, entry := range entries {
+			names[n] = entry.Name()
+		}
+		return NewList(names), nil
+	})
+	i.DefineNative("joinPath", -1, func(_ *Interpreter, args []any) (any, error) {
+		parts := make([]string, len(args))
+		for n, arg := range args {
+			part, err := pathArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			parts[n] = part
+		}
+		return filepath.Join(parts...), nil
+	})
+	i.DefineNative("basename", 1, func(_ *Interpreter, args []any) (any, error) {
+		path, err := pathArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return filepath.Base(path), nil
+	})
+	i.DefineNative("dirname", 1, func(_ *Interpreter, args []any) (any, error) {
+		path, err := pathArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return filepath.Dir(path), nil
+	})
+	i.defineCapability("fs", "mkdir", 1, func(_ *Interpreter, args []any) (any, error) {
+		path, err := pathArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(path, 0o777); err != nil {
+			return nil, fsError("create", path, err)
+		}
+		return nil, nil
+	})
+	i.defineCapability("fs", "remove", 1, func(_ *Interpreter, args []any) (any, error) {
+		path, err := pathArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fsError("remove", path, err)
+		}
+		return nil, nil
+	})
+	i.defineCapability("fs", "stat", 1, func(_ *Interpreter, args []any) (any, error) {
+		path, err := pathArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fsError("stat", path, err)
+		}
+		return &fileStat{info}, nil
+	})
+}
+
+// includeNative stands for //#include directives in the audit log.
+var includeNative = &nativeFunction{name: "include", arity: 1, capability: "fs"}
+
+// ReadInclude reads the file an //#include directive at site names, as
+// readFile would, and records the read in the audit log as a call to
+// include. It suits scanner.SetIncludeReader for hosts that let scripts
+// include files only when they grant the file system capability.
+func (i *Interpreter) ReadInclude(site token.Token, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if i.Audit != nil {
+		i.Audit.record(site, includeNative, []any{path}, err)
+	}
+	return data, err
+}
+
+func pathArg(value any) (string, error) {
+	if path, ok := value.(string); ok {
+		return path, nil
+	}
+	return "", errors.New("Path must be a string.")
+}
+
+// fsError describes a failed file operation without repeating the path
+// that os errors already include.
+func fsError(action, path string, err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return fmt.Errorf("Can't %s '%s': %v.", action, path, err)
+}
+
+// fileStat is the result of stat.
+type fileStat struct {
+	info os.FileInfo
+}
+
+func (s *fileStat) Get(name string) (any, error) {
+	switch name {
+	case "size":
+		return float64(s.info.Size()), nil
+	case "mtime":
+		return float64(s.info.ModTime().UnixNano()) / 1e9, nil
+	case "isDir":
+		return s.info.IsDir(), nil
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (s *fileStat) Set(name string, _ any) error {
+	return fmt.Errorf("Can't assign property '%s' of a stat result.", name)
+}
+
+func (s *fileStat) String() string {
+	return "<stat " + s.info.Name() + ">"
+}
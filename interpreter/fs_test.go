@@ -0,0 +1,49 @@
+package interpreter
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	q := strconv.Quote
+	in := func(name string) string { return filepath.Join(dir, name) }
+	fs := func(i *Interpreter) { i.EnableFileSystem() }
+	runPrograms(t, []programTest{
+		{name: "write, read and stat", source: `
+			var path = joinPath(` + q(dir) + `, "a.txt");
+			writeFile(path, "héllo");
+			print readFile(path);
+			var info = stat(path);
+			print info.size; print info.isDir; print info.mtime > 0;
+			print stat(` + q(dir) + `).isDir;
+			print stat(joinPath(` + q(dir) + `, "none"));`,
+			want: "héllo\n6\nfalse\ntrue\ntrue\nnil\n", setup: fs},
+		{name: "mkdir, listDir and remove", source: `
+			var sub = joinPath(` + q(dir) + `, "x", "y");
+			mkdir(sub);
+			mkdir(sub);
+			writeFile(joinPath(sub, "b"), "");
+			writeFile(joinPath(sub, "a"), "");
+			print listDir(sub);
+			remove(joinPath(sub, "a"));
+			print listDir(sub);`,
+			want: "[a, b]\n[b]\n", setup: fs},
+		{name: "path helpers", source: `
+			print basename("a/b/c.lox"); print dirname("a/b/c.lox"); print joinPath("a", "b") == "a" + "` + string(filepath.Separator) + `b";
+			print joinPath();`,
+			want: "c.lox\na" + string(filepath.Separator) + "b\ntrue\n\n", setup: fs},
+		{name: "read a missing file", source: `readFile(` + q(in("none")) + `);`, err: "Can't read '" + in("none") + "': no such file or directory.", setup: fs},
+		{name: "list a missing directory", source: `listDir(` + q(in("none")) + `);`, err: "Can't list '" + in("none") + "': no such file or directory.", setup: fs},
+		{name: "remove a missing file", source: `remove(` + q(in("none")) + `);`, err: "Can't remove '" + in("none") + "': no such file or directory.", setup: fs},
+		{name: "write into a missing directory", source: `writeFile(` + q(in("none/a")) + `, "");`, err: "Can't write '" + in("none/a") + "': no such file or directory.", setup: fs},
+		{name: "write a number", source: `writeFile(` + q(in("n")) + `, 1);`, err: "Can only write strings.", setup: fs},
+		{name: "path not a string", source: `readFile(1);`, err: "Path must be a string.", setup: fs},
+		{name: "join non-strings", source: `joinPath("a", nil);`, err: "Path must be a string.", setup: fs},
+		{name: "stat fields are read-only", source: `stat(` + q(dir) + `).size = 1;`, err: "Can't assign property 'size' of a stat result.", setup: fs},
+		{name: "unknown stat field", source: `stat(` + q(dir) + `).owner;`, err: "Undefined property 'owner'.", setup: fs},
+		{name: "disabled by default", source: `readFile("a");`, err: "Undefined variable 'readFile'."},
+	})
+}
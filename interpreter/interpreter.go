@@ -0,0 +1,740 @@
+// Package interpreter runs Lox programs by walking their syntax trees.
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/internal/number"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// Interpreter evaluates a parsed program by walking its syntax tree.
+//
+// Lox values are represented by plain Go values: nil, bool, float64 and
+// string, plus *big.Int for large integers when BigInt is set.
+type Interpreter struct {
+	// BigInt makes integer arithmetic exact beyond 2^53: integral results
+	// that a float64 cannot hold are promoted to *big.Int.
+	BigInt bool
+	// LineCosts counts evaluations per source line, for cost reports. It
+	// is nil, and costs nothing, unless the caller sets it.
+	LineCosts map[SourceLine]int
+	// Strict makes it a runtime error to read a variable that was declared
+	// without an initializer and has not been assigned since.
+	Strict bool
+	// MaxDepth limits how deeply calls may nest. Exceeding it is a runtime
+	// error rather than a crash of the host. New sets it to
+	// DefaultMaxDepth; zero means no limit.
+	MaxDepth int
+	// Mutations, if set, records every variable definition and
+	// assignment.
+	Mutations *MutationLog
+	// Audit, if set, records every call to the natives that reach outside
+	// the interpreter, such as the file and network natives.
+	Audit *AuditLog
+
+	stdout      io.Writer
+	globals     *Environment
+	environment *Environment
+	// locals holds the scope distance of every local variable reference,
+	// as computed by the Resolver. References missing from it are globals.
+	locals  map[ast.Expr]int
+	depth   int
+	events  events
+	signals signals
+	// callSite is the call expression that is about to call a function,
+	// for the audit log.
+	callSite token.Token
+}
+
+// SourceLine is a line of a program, as LineCosts counts them. File is
+// empty for the source being run and names the file otherwise, for code
+// that was included or attributed to another file by a directive.
+type SourceLine struct {
+	File string
+	Line int
+}
+
+// unassigned is the value of a variable declared without an initializer
+// in strict mode, until something is assigned to it.
+type unassigned struct{}
+
+// RuntimeError is an error raised while evaluating, located at the token
+// whose evaluation failed. Trace lists the calls the error unwound
+// through, innermost first; it is empty for an error in top-level code.
+type RuntimeError struct {
+	Token   token.Token
+	Message string
+	Trace   []Frame
+}
+
+// Frame is a function call that was in progress when a runtime error
+// occurred.
+type Frame struct {
+	// Function is the name of the function, class or native called.
+	Function string
+	// Call is the closing parenthesis of the call expression, or the zero
+	// Token if the function was called from Go, such as by a native.
+	Call token.Token
+}
+
+func (e *RuntimeError) Error() string { return e.Message }
+
+// DefaultMaxDepth bounds call nesting unless MaxDepth says otherwise, so
+// that runaway recursion in a script fails with a runtime error instead of
+// exhausting the host's stack.
+const DefaultMaxDepth = 10000
+
+// New returns an interpreter whose print statements write to stdout. Its
+// global scope persists across calls to Interpret, so a REPL can feed it
+// one line at a time.
+func New(stdout io.Writer) *Interpreter {
+	globals := NewEnvironment(nil)
+	defineNatives(globals)
+	return &Interpreter{
+		stdout:      stdout,
+		globals:     globals,
+		environment: globals,
+		locals:      map[ast.Expr]int{},
+		MaxDepth:    DefaultMaxDepth,
+	}
+}
+
+// Interpret executes statements in order, stopping at the first runtime
+// error, which it returns as a *RuntimeError, or at a call to exit, which
+// it returns as an *ExitError. Statements must have been passed through a
+// Resolver first.
+func (i *Interpreter) Interpret(statements []ast.Stmt) error {
+	for _, stmt := range statements {
+		if err := i.execute(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluate evaluates a single expression in the global scope. Like
+// Interpret, it requires the expression to have been resolved.
+func (i *Interpreter) Evaluate(expr ast.Expr) (any, error) {
+	return i.evaluate(expr)
+}
+
+// resolve is called by the Resolver for each local variable reference.
+func (i *Interpreter) resolve(expr ast.Expr, depth int) {
+	i.locals[expr] = depth
+}
+
+func (i *Interpreter) lookUpVariable(name token.Token, expr ast.Expr) (any, error) {
+	var value any
+	if distance, ok := i.locals[expr]; ok {
+		value = i.environment.getAt(distance, name.Lexeme)
+	} else {
+		var err error
+		if value, err = i.globals.get(name); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := value.(unassigned); ok {
+		return nil, &RuntimeError{Token: name, Message: "Variable '" + name.Lexeme + "' is used before being assigned."}
+	}
+	return value, nil
+}
+
+func (i *Interpreter) execute(stmt ast.Stmt) error {
+	if i.signals.pending != nil {
+		if err := i.handleSignals(); err != nil {
+			return err
+		}
+	}
+	return stmt.Accept(i)
+}
+
+func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
+	if i.LineCosts != nil {
+		file, line := ast.ExprPos(expr)
+		i.LineCosts[SourceLine{file, line}]++
+	}
+	return expr.Accept(i)
+}
+
+// executeBlock runs statements in the given scope, restoring the current
+// one afterwards even when a statement fails.
+func (i *Interpreter) executeBlock(statements []ast.Stmt, environment *Environment) error {
+	previous := i.environment
+	i.environment = environment
+	defer func() { i.environment = previous }()
+
+	for _, stmt := range statements {
+		if err := i.execute(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitBlockStmt(stmt *ast.BlockStmt) error {
+	return i.executeBlock(stmt.Statements, NewEnvironment(i.environment))
+}
+
+func (i *Interpreter) VisitBreakStmt(stmt *ast.BreakStmt) error {
+	return &loopJump{stmt.Keyword}
+}
+
+func (i *Interpreter) VisitClassStmt(stmt *ast.ClassStmt) error {
+	var superclass *LoxClass
+	if stmt.Superclass != nil {
+		value, err := i.evaluate(stmt.Superclass)
+		if err != nil {
+			return err
+		}
+		class, ok := value.(*LoxClass)
+		if !ok {
+			return &RuntimeError{Token: stmt.Superclass.Name, Message: "Superclass must be a class."}
+		}
+		superclass = class
+	}
+
+	i.environment.define(stmt.Name.Lexeme, nil)
+
+	// Methods of a subclass close over an extra scope holding "super".
+	environment := i.environment
+	if superclass != nil {
+		environment = NewEnvironment(environment)
+		environment.define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction, len(stmt.Methods))
+	if superclass != nil {
+		maps.Copy(methods, superclass.methods)
+	}
+	for _, method := range stmt.Methods {
+		methods[method.Name.Lexeme] = &LoxFunction{
+			declaration:   method,
+			closure:       environment,
+			isInitializer: method.Name.Lexeme == "init",
+		}
+	}
+
+	class := &LoxClass{name: stmt.Name.Lexeme, superclass: superclass, methods: methods}
+	return i.environment.assign(stmt.Name, class)
+}
+
+func (i *Interpreter) VisitContinueStmt(stmt *ast.ContinueStmt) error {
+	return &loopJump{stmt.Keyword}
+}
+
+func (i *Interpreter) VisitExpressionStmt(stmt *ast.ExpressionStmt) error {
+	_, err := i.evaluate(stmt.Expression)
+	return err
+}
+
+func (i *Interpreter) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
+	i.environment.define(stmt.Name.Lexeme, &LoxFunction{declaration: stmt, closure: i.environment})
+	return nil
+}
+
+func (i *Interpreter) VisitIfStmt(stmt *ast.IfStmt) error {
+	condition, err := i.evaluate(stmt.Condition)
+	if err != nil {
+		return err
+	}
+	if isTruthy(condition) {
+		return i.execute(stmt.ThenBranch)
+	}
+	if stmt.ElseBranch != nil {
+		return i.execute(stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitPrintStmt(stmt *ast.PrintStmt) error {
+	value, err := i.evaluate(stmt.Expression)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(i.stdout, Stringify(value))
+	return nil
+}
+
+func (i *Interpreter) VisitReturnStmt(stmt *ast.ReturnStmt) error {
+	var value any
+	if stmt.Value != nil {
+		var err error
+		if value, err = i.evaluate(stmt.Value); err != nil {
+			return err
+		}
+	}
+	return &returnValue{value}
+}
+
+func (i *Interpreter) VisitVarStmt(stmt *ast.VarStmt) error {
+	var value any
+	if stmt.Initializer == nil && i.Strict {
+		value = unassigned{}
+	}
+	if stmt.Initializer != nil {
+		var err error
+		if value, err = i.evaluate(stmt.Initializer); err != nil {
+			return err
+		}
+	}
+	i.environment.define(stmt.Name.Lexeme, value)
+	if i.Mutations != nil {
+		i.Mutations.record(Mutation{Kind: Defined, Name: stmt.Name.Lexeme, New: value, File: stmt.Name.File, Line: stmt.Name.Line})
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitWhileStmt(stmt *ast.WhileStmt) error {
+	for {
+		condition, err := i.evaluate(stmt.Condition)
+		if err != nil {
+			return err
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+		if err := i.execute(stmt.Body); err != nil {
+			jump, ok := err.(*loopJump)
+			if !ok {
+				return err
+			}
+			if jump.keyword.Type == token.Break {
+				return nil
+			}
+		}
+		if stmt.Increment != nil {
+			if _, err := i.evaluate(stmt.Increment); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// loopJump carries a break or continue statement up through the
+// statements of a loop body to the loop, as returnValue does for return.
+type loopJump struct {
+	keyword token.Token
+}
+
+func (j *loopJump) Error() string {
+	return "Can't use '" + j.keyword.Lexeme + "' outside of a loop."
+}
+
+func (i *Interpreter) VisitAssignExpr(expr *ast.AssignExpr) (any, error) {
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.assignVariable(expr, expr.Name, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// assignVariable stores value into the variable name, which the resolver
+// resolved as expr.
+func (i *Interpreter) assignVariable(expr ast.Expr, name token.Token, value any) error {
+	distance, local := i.locals[expr]
+	var old any
+	if i.Mutations != nil {
+		if local {
+			old = i.environment.getAt(distance, name.Lexeme)
+		} else {
+			old, _ = i.globals.get(name)
+		}
+	}
+	if local {
+		i.environment.assignAt(distance, name, value)
+	} else if err := i.globals.assign(name, value); err != nil {
+		return err
+	}
+	if i.Mutations != nil {
+		i.Mutations.record(Mutation{Kind: Assigned, Name: name.Lexeme, Old: old, New: value, File: name.File, Line: name.Line})
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitVariableExpr(expr *ast.VariableExpr) (any, error) {
+	return i.lookUpVariable(expr.Name, expr)
+}
+
+func (i *Interpreter) VisitBinaryExpr(expr *ast.BinaryExpr) (any, error) {
+	left, err := i.evaluate(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := i.evaluate(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.Operator.Type {
+	case token.EqualEqual:
+		return isEqual(left, right), nil
+	case token.BangEqual:
+		return !isEqual(left, right), nil
+	}
+
+	if i.BigInt {
+		if result, ok := bigBinary(expr.Operator.Type, left, right); ok {
+			return result, nil
+		}
+	}
+
+	if expr.Operator.Type == token.Plus {
+		if l, ok := toNumber(left); ok {
+			if r, ok := toNumber(right); ok {
+				return l + r, nil
+			}
+		}
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		}
+		return nil, &RuntimeError{Token: expr.Operator, Message: "Operands must be two numbers or two strings."}
+	}
+
+	l, r, err := checkNumberOperands(expr.Operator, left, right)
+	if err != nil {
+		return nil, err
+	}
+	switch expr.Operator.Type {
+	case token.Minus:
+		return l - r, nil
+	case token.Star:
+		return l * r, nil
+	case token.Slash:
+		return l / r, nil
+	case token.Percent:
+		if r == 0 {
+			return nil, &RuntimeError{Token: expr.Operator, Message: "Division by zero."}
+		}
+		return floorMod(l, r), nil
+	case token.Greater:
+		return l > r, nil
+	case token.GreaterEqual:
+		return l >= r, nil
+	case token.Less:
+		return l < r, nil
+	case token.LessEqual:
+		return l <= r, nil
+	}
+	return nil, &RuntimeError{Token: expr.Operator, Message: "Unknown operator '" + expr.Operator.Lexeme + "'."}
+}
+
+func (i *Interpreter) VisitCallExpr(expr *ast.CallExpr) (any, error) {
+	callee, err := i.evaluate(expr.Callee)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := make([]any, 0, len(expr.Arguments))
+	for _, argument := range expr.Arguments {
+		value, err := i.evaluate(argument)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, value)
+	}
+
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		return nil, &RuntimeError{Token: expr.Paren, Message: "Can only call functions and classes."}
+	}
+	i.callSite = expr.Paren
+	result, err := i.Call(function, arguments)
+	if err != nil {
+		if exit, ok := err.(*ExitError); ok {
+			return nil, exit
+		}
+		rt, ok := err.(*RuntimeError)
+		if !ok {
+			// Errors from natives carry no position; blame the call.
+			return nil, &RuntimeError{Token: expr.Paren, Message: err.Error()}
+		}
+		if n := len(rt.Trace); n > 0 && rt.Trace[n-1].Call.Line == 0 {
+			rt.Trace[n-1].Call = expr.Paren
+		}
+		return nil, rt
+	}
+	return result, nil
+}
+
+// Call calls function with arguments as a call expression in Lox would,
+// checking the number of arguments and the call depth first.
+func (i *Interpreter) Call(function LoxCallable, arguments []any) (any, error) {
+	if arity := function.Arity(); arity >= 0 && len(arguments) != arity {
+		return nil, fmt.Errorf("Expected %d arguments but got %d.", arity, len(arguments))
+	}
+	if i.MaxDepth > 0 && i.depth >= i.MaxDepth {
+		return nil, errors.New("Stack overflow.")
+	}
+	// A native called by another native, as sort calls its comparator, is
+	// audited at the call to the outer one.
+	site := i.callSite
+	i.depth++
+	defer func() { i.depth-- }()
+	result, err := function.Call(i, arguments)
+	if native, ok := function.(*nativeFunction); ok && native.capability != "" && i.Audit != nil {
+		i.Audit.record(site, native, arguments, err)
+	}
+	if rt, ok := err.(*RuntimeError); ok {
+		rt.Trace = append(rt.Trace, Frame{Function: callableName(function)})
+	}
+	return result, err
+}
+
+func (i *Interpreter) VisitConditionalExpr(expr *ast.ConditionalExpr) (any, error) {
+	condition, err := i.evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if isTruthy(condition) {
+		return i.evaluate(expr.ThenBranch)
+	}
+	return i.evaluate(expr.ElseBranch)
+}
+
+func (i *Interpreter) VisitFunctionExpr(expr *ast.FunctionExpr) (any, error) {
+	return &LoxFunction{declaration: expr.Function, closure: i.environment}, nil
+}
+
+func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) (any, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	return getProperty(object, expr.Name)
+}
+
+func getProperty(object any, name token.Token) (any, error) {
+	switch object := object.(type) {
+	case *LoxInstance:
+		return object.get(name)
+	case Object:
+		value, err := object.Get(name.Lexeme)
+		if err != nil {
+			return nil, &RuntimeError{Token: name, Message: err.Error()}
+		}
+		return value, nil
+	}
+	return nil, &RuntimeError{Token: name, Message: "Only instances have properties."}
+}
+
+func (i *Interpreter) VisitGroupingExpr(expr *ast.GroupingExpr) (any, error) {
+	return i.evaluate(expr.Expression)
+}
+
+func (i *Interpreter) VisitIncrementExpr(expr *ast.IncrementExpr) (any, error) {
+	var object, old any
+	var err error
+	switch target := expr.Target.(type) {
+	case *ast.VariableExpr:
+		old, err = i.lookUpVariable(target.Name, target)
+	case *ast.GetExpr:
+		// The object is evaluated once, for both the read and the write.
+		if object, err = i.evaluate(target.Object); err == nil {
+			old, err = getProperty(object, target.Name)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	step := 1.0
+	if expr.Operator.Type == token.MinusMinus {
+		step = -1
+	}
+	var value any
+	if n, ok := old.(*big.Int); ok {
+		value = normalizeBig(new(big.Int).Add(n, big.NewInt(int64(step))))
+	} else if n, ok := toNumber(old); ok {
+		value = n + step
+	} else {
+		return nil, &RuntimeError{Token: expr.Operator, Message: "Operand must be a number."}
+	}
+
+	switch target := expr.Target.(type) {
+	case *ast.VariableExpr:
+		err = i.assignVariable(target, target.Name, value)
+	case *ast.GetExpr:
+		err = setProperty(object, target.Name, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expr.Prefix {
+		return value, nil
+	}
+	return old, nil
+}
+
+func (i *Interpreter) VisitInterpolationExpr(expr *ast.InterpolationExpr) (any, error) {
+	var b strings.Builder
+	for _, part := range expr.Parts {
+		value, err := i.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(Stringify(value))
+	}
+	return b.String(), nil
+}
+
+func (i *Interpreter) VisitLiteralExpr(expr *ast.LiteralExpr) (any, error) {
+	return expr.Value, nil
+}
+
+func (i *Interpreter) VisitLogicalExpr(expr *ast.LogicalExpr) (any, error) {
+	left, err := i.evaluate(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	if expr.Operator.Type == token.Or {
+		if isTruthy(left) {
+			return left, nil
+		}
+	} else if !isTruthy(left) {
+		return left, nil
+	}
+	return i.evaluate(expr.Right)
+}
+
+func (i *Interpreter) VisitSetExpr(expr *ast.SetExpr) (any, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	switch object.(type) {
+	case *LoxInstance, Object:
+	default:
+		return nil, &RuntimeError{Token: expr.Name, Message: "Only instances have fields."}
+	}
+
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := setProperty(object, expr.Name, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// setProperty sets a field of object, which must be a *LoxInstance or an
+// Object.
+func setProperty(object any, name token.Token, value any) error {
+	if instance, ok := object.(*LoxInstance); ok {
+		instance.set(name, value)
+	} else if err := object.(Object).Set(name.Lexeme, value); err != nil {
+		return &RuntimeError{Token: name, Message: err.Error()}
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitSuperExpr(expr *ast.SuperExpr) (any, error) {
+	distance := i.locals[expr]
+	superclass := i.environment.getAt(distance, "super").(*LoxClass)
+	// Bound methods define "this" in the scope just inside the one holding
+	// "super".
+	object := i.environment.getAt(distance-1, "this")
+
+	method := superclass.findMethod(expr.Method.Lexeme)
+	if method == nil {
+		return nil, &RuntimeError{Token: expr.Method, Message: "Undefined property '" + expr.Method.Lexeme + "'."}
+	}
+	return method.bind(object.(*LoxInstance)), nil
+}
+
+func (i *Interpreter) VisitThisExpr(expr *ast.ThisExpr) (any, error) {
+	// The resolver has found "this" in the scope of a method: the keyword
+	// can't name a global, and may be spelled with an alias.
+	return i.environment.getAt(i.locals[expr], "this"), nil
+}
+
+func (i *Interpreter) VisitUnaryExpr(expr *ast.UnaryExpr) (any, error) {
+	right, err := i.evaluate(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.Operator.Type {
+	case token.Bang:
+		return !isTruthy(right), nil
+	case token.Minus:
+		if n, ok := right.(*big.Int); ok {
+			return normalizeBig(new(big.Int).Neg(n)), nil
+		}
+		r, ok := toNumber(right)
+		if !ok {
+			return nil, &RuntimeError{Token: expr.Operator, Message: "Operand must be a number."}
+		}
+		return -r, nil
+	}
+	return nil, &RuntimeError{Token: expr.Operator, Message: "Unknown operator '" + expr.Operator.Lexeme + "'."}
+}
+
+func checkNumberOperands(operator token.Token, left, right any) (float64, float64, error) {
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return 0, 0, &RuntimeError{Token: operator, Message: "Operands must be numbers."}
+	}
+	return l, r, nil
+}
+
+// floorMod returns the remainder of dividing l by r, rounding the quotient
+// down, so that the result has the sign of r: -7 % 3 is 2, as in Python,
+// which suits wrapping an index around a range.
+func floorMod(l, r float64) float64 {
+	m := math.Mod(l, r)
+	switch {
+	case m == 0:
+		return 0 // not -0
+	case (m < 0) != (r < 0):
+		m += r
+	}
+	return m
+}
+
+// isTruthy follows Ruby: false and nil are falsey, everything else is
+// truthy.
+func isTruthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
+}
+
+func isEqual(a, b any) bool {
+	if isBig(a) || isBig(b) {
+		return bigEqual(a, b)
+	}
+	return a == b
+}
+
+// Stringify formats a value the way print shows it. Unlike token literals,
+// integral numbers are printed without a trailing ".0".
+func Stringify(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return number.Format(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,393 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/parser"
+	"github.com/kriyanshii/interpreter-go/scanner"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// prepare scans, parses and resolves source for an interpreter that
+// discards its output.
+func prepare(tb testing.TB, source string) (*Interpreter, func() error) {
+	tb.Helper()
+	tokens := scanner.New(source, func(file string, line, column int, message string) {
+		tb.Fatalf("line %d, col %d: %s", line, column, message)
+	}, 0).ScanTokens()
+	fail := func(tok token.Token, message string) {
+		tb.Fatalf("line %d at '%s': %s", tok.Line, tok.Lexeme, message)
+	}
+	statements := parser.New(tokens, fail).Parse()
+	i := New(io.Discard)
+	NewResolver(i, fail).Resolve(statements)
+	return i, func() error { return i.Interpret(statements) }
+}
+
+// output runs source and returns what it printed, failing the test if it
+// fails.
+func output(t *testing.T, source string) string {
+	t.Helper()
+	i, run := prepare(t, source)
+	var out bytes.Buffer
+	i.stdout = &out
+	if err := run(); err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	return out.String()
+}
+
+// programTest is a program and what it prints. If err is set, the program
+// fails with it after printing want: a compile error as "line N at 'x':
+// message", or the message of a runtime error. setup, if set, prepares
+// the interpreter first, such as by enabling natives.
+type programTest struct {
+	name   string
+	source string
+	want   string
+	err    string
+	setup  func(*Interpreter)
+}
+
+// runProgram runs source and returns what it printed and the first error
+// it reported, whether while compiling or running. Source is scanned in
+// the bigint dialect if setup turns it on.
+func runProgram(source string, setup func(*Interpreter)) (string, string) {
+	var out bytes.Buffer
+	i := New(&out)
+	if setup != nil {
+		setup(i)
+	}
+	var mode scanner.Mode
+	if i.BigInt {
+		mode |= scanner.BigInts
+	}
+	var errs []string
+	tokens := scanner.New(source, func(file string, line, column int, message string) {
+		errs = append(errs, fmt.Sprintf("line %d: %s", line, message))
+	}, mode).ScanTokens()
+	report := func(tok token.Token, message string) {
+		errs = append(errs, fmt.Sprintf("line %d at '%s': %s", tok.Line, tok.Lexeme, message))
+	}
+	statements := parser.New(tokens, report).Parse()
+	if len(errs) == 0 {
+		NewResolver(i, report).Resolve(statements)
+	}
+	if len(errs) > 0 {
+		return "", errs[0]
+	}
+	if err := i.Interpret(statements); err != nil {
+		return out.String(), err.Error()
+	}
+	return out.String(), ""
+}
+
+func runPrograms(t *testing.T, tests []programTest) {
+	t.Helper()
+	for _, test := range tests {
+		got, err := runProgram(test.source, test.setup)
+		if got != test.want || err != test.err {
+			t.Errorf("%s: got %q and error %q,\nwant %q and error %q", test.name, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestStatements(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "print", source: `print 1; print "two"; print nil; print true;`, want: "1\ntwo\nnil\ntrue\n"},
+		{name: "var", source: `var a; var b = 2; print a; print b; b = 3; print b;`, want: "nil\n2\n3\n"},
+		{name: "blocks", source: `
+			var a = "global";
+			{
+				var a = "outer";
+				{ var a = "inner"; print a; }
+				print a;
+			}
+			print a;`, want: "inner\nouter\nglobal\n"},
+		{name: "if", source: `
+			if (1 < 2) print "then"; else print "else";
+			if (nil) print "then"; else print "else";
+			if (false) print "skipped";`, want: "then\nelse\n"},
+		{name: "dangling else", source: `if (true) if (false) print 1; else print 2;`, want: "2\n"},
+		{name: "while", source: `var i = 0; while (i < 3) { print i; i = i + 1; }`, want: "0\n1\n2\n"},
+		{name: "for", source: `for (var i = 0; i < 3; i = i + 1) print i;`, want: "0\n1\n2\n"},
+		{name: "for without clauses", source: `var i = 0; for (; i < 2;) { print i; i = i + 1; }`, want: "0\n1\n"},
+		{name: "for scope", source: `var i = "outer"; for (var i = 0; i < 1; i = i + 1) {} print i;`, want: "outer\n"},
+		{name: "logical", source: `print nil or "default"; print 1 and 2; print false and undefined;`, want: "default\n2\nfalse\n"},
+		{name: "missing semicolon", source: `print 1`, err: "line 1 at '': Expect ';' after value."},
+		{name: "undefined", source: `print 1; print missing;`, want: "1\n", err: "Undefined variable 'missing'."},
+	})
+}
+
+func TestExpressions(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "arithmetic", source: `print 1 + 2 * 3; print (1 + 2) * 3; print 10 / 4; print -2 - -3;`, want: "7\n9\n2.5\n1\n"},
+		{name: "comparison", source: `print 1 < 2; print 2 <= 1; print 3 > 3; print 3 >= 3;`, want: "true\nfalse\nfalse\ntrue\n"},
+		{name: "equality", source: `print 1 == 1.0; print "a" != "a"; print nil == false; print nil == nil;`, want: "true\nfalse\nfalse\ntrue\n"},
+		{name: "truthiness", source: `print !nil; print !0; print !""; print !!false;`, want: "true\nfalse\nfalse\nfalse\n"},
+		{name: "concatenation", source: `print "con" + "cat";`, want: "concat\n"},
+		{name: "number formatting", source: `print 3.0; print 0.1 + 0.2; print 1e21;`, want: "3\n0.30000000000000004\n1000000000000000000000\n"},
+		{name: "bad operand", source: `print -"a";`, err: "Operand must be a number."},
+		{name: "bad operands", source: `print 1 < "2";`, err: "Operands must be numbers."},
+		{name: "mixed addition", source: `print 1 + nil;`, err: "Operands must be two numbers or two strings."},
+	})
+}
+
+func TestFunctions(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "call", source: `fun add(a, b) { return a + b; } print add(1, 2);`, want: "3\n"},
+		{name: "implicit nil", source: `fun f() {} print f(); fun g() { return; } print g();`, want: "nil\nnil\n"},
+		{name: "early return", source: `fun f(n) { while (true) { if (n > 2) return n; n = n + 1; } } print f(0);`, want: "3\n"},
+		{name: "recursion", source: `fun fib(n) { if (n < 2) return n; return fib(n - 1) + fib(n - 2); } print fib(10);`, want: "55\n"},
+		{name: "printing", source: `fun f() {} print f; print clock;`, want: "<fn f>\n<native fn>\n"},
+		{name: "closure counter", source: `
+			fun makeCounter() {
+				var count = 0;
+				fun counter() { count = count + 1; return count; }
+				return counter;
+			}
+			var a = makeCounter();
+			var b = makeCounter();
+			print a(); print a(); print b();`, want: "1\n2\n1\n"},
+		{name: "closure binding", source: `
+			var a = "global";
+			{
+				fun show() { print a; }
+				show();
+				var a = "block";
+				show();
+			}`, want: "global\nglobal\n"},
+		{name: "arity", source: `fun f(a) {} f(1, 2);`, err: "Expected 1 arguments but got 2."},
+		{name: "not callable", source: `"text"();`, err: "Can only call functions and classes."},
+		{name: "top-level return", source: `return 1;`, err: "line 1 at 'return': Can't return from top-level code."},
+		{name: "own initializer", source: `{ var a = 1; { var a = a; } }`, err: "line 1 at 'a': Can't read local variable in its own initializer."},
+	})
+}
+
+func TestClasses(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "fields", source: `class Box {} var b = Box(); b.value = 1; print b.value; print b; print Box;`, want: "1\nBox instance\nBox\n"},
+		{name: "methods and this", source: `
+			class Counter {
+				init(start) { this.n = start; }
+				tick() { this.n = this.n + 1; return this; }
+			}
+			print Counter(5).tick().tick().n;`, want: "7\n"},
+		{name: "bound method", source: `
+			class Greeter { init(name) { this.name = name; } hi() { print "hi " + this.name; } }
+			var hi = Greeter("ann").hi;
+			hi();`, want: "hi ann\n"},
+		{name: "initializer returns this", source: `
+			class A { init() { this.x = 1; return; } }
+			var a = A();
+			print a.init() == a;`, want: "true\n"},
+		{name: "inheritance and super", source: `
+			class Animal { speak() { return "..."; } name() { return "animal"; } }
+			class Dog < Animal {
+				speak() { return "woof after " + super.speak(); }
+			}
+			var d = Dog();
+			print d.speak(); print d.name();`, want: "woof after ...\nanimal\n"},
+		{name: "super in closure", source: `
+			class A { say() { print "A"; } }
+			class B < A { say() { fun later() { super.say(); } return later; } }
+			B().say()();`, want: "A\n"},
+		{name: "undefined property", source: `class A {} A().missing;`, err: "Undefined property 'missing'."},
+		{name: "property of non-instance", source: `var x = 1; print x.y;`, err: "Only instances have properties."},
+		{name: "bad superclass", source: `var NotAClass = 1; class A < NotAClass {}`, err: "Superclass must be a class."},
+		{name: "inherit from itself", source: `class A < A {}`, err: "line 1 at 'A': A class can't inherit from itself."},
+		{name: "value from initializer", source: `class A { init() { return 1; } }`, err: "line 1 at 'return': Can't return a value from an initializer."},
+		{name: "this outside class", source: `print this;`, err: "line 1 at 'this': Can't use 'this' outside of a class."},
+		{name: "super without superclass", source: `class A { f() { super.f(); } }`, err: "line 1 at 'super': Can't use 'super' in a class with no superclass."},
+	})
+}
+
+func TestBreakContinue(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "break", source: `var i = 0; while (true) { if (i == 3) break; print i; i = i + 1; }`, want: "0\n1\n2\n"},
+		{name: "continue runs the increment", source: `for (var i = 0; i < 5; i = i + 1) { if (i % 2 == 0) continue; print i; }`, want: "1\n3\n"},
+		{name: "innermost loop", source: `
+			for (var i = 0; i < 2; i = i + 1) {
+				for (var j = 0; j < 5; j = j + 1) {
+					if (j == 1) break;
+					print i + j * 10;
+				}
+			}`, want: "0\n1\n"},
+		{name: "from a nested block", source: `var i = 0; while (i < 10) { { i = i + 1; if (i > 2) { break; } } } print i;`, want: "3\n"},
+		{name: "out of a function's loop", source: `
+			fun find(n) { var i = 0; while (true) { if (i * i >= n) break; i = i + 1; } return i; }
+			print find(10);`, want: "4\n"},
+		{name: "break outside loop", source: `break;`, err: "line 1 at 'break': Can't use 'break' outside of a loop."},
+		{name: "continue outside loop", source: `if (true) continue;`, err: "line 1 at 'continue': Can't use 'continue' outside of a loop."},
+		{name: "break in function in loop", source: `while (true) { fun f() { break; } }`, err: "line 1 at 'break': Can't use 'break' outside of a loop."},
+		{name: "missing semicolon", source: `while (true) { break }`, err: "line 1 at '}': Expect ';' after 'break'."},
+	})
+}
+
+func TestConditional(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "branches", source: `print true ? "yes" : "no"; print nil ? "yes" : "no";`, want: "yes\nno\n"},
+		{name: "right-associative", source: `
+			fun sign(n) { return n < 0 ? "negative" : n == 0 ? "zero" : "positive"; }
+			print sign(-1); print sign(0); print sign(1);`, want: "negative\nzero\npositive\n"},
+		{name: "below or", source: `print false or true ? 1 : 2;`, want: "1\n"},
+		{name: "above assignment", source: `var a; a = false ? 1 : 2; print a;`, want: "2\n"},
+		{name: "assignment in branch", source: `var a; var b; true ? a = 1 : (b = 2); print a; print b;`, want: "1\nnil\n"},
+		{name: "only the chosen branch runs", source: `
+			fun loud(s) { print s; return s; }
+			print true ? loud("then") : loud("else");`, want: "then\nthen\n"},
+		{name: "missing colon", source: `print true ? 1;`, err: "line 1 at ';': Expect ':' after then branch of conditional expression."},
+	})
+}
+
+func TestRemainder(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "positive", source: `print 7 % 3; print 6 % 3; print 5.5 % 2;`, want: "1\n0\n1.5\n"},
+		{name: "sign of the divisor", source: `print -7 % 3; print 7 % -3; print -7 % -3;`, want: "2\n-2\n-1\n"},
+		{name: "factor precedence", source: `print 1 + 7 % 4 * 2; print 2 * 7 % 4;`, want: "7\n2\n"},
+		{name: "by zero", source: `print 1 % 0;`, err: "Division by zero."},
+		{name: "not a number", source: `print "7" % 2;`, err: "Operands must be numbers."},
+	})
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "variables", source: `var a = 10; a += 5; print a; a -= 3; print a; a *= 2; print a; a /= 4; print a; a %= 4; print a;`, want: "15\n12\n24\n6\n2\n"},
+		{name: "strings", source: `var s = "a"; s += "b"; print s;`, want: "ab\n"},
+		{name: "value of the expression", source: `var a = 1; print a += 2; var b = 1; var c = 1; b += c += 1; print b; print c;`, want: "3\n3\n2\n"},
+		{name: "right side first", source: `var a = 2; a *= 1 + 2; print a;`, want: "6\n"},
+		{name: "locals and closures", source: `
+			fun counter() { var n = 0; fun add(k) { n += k; return n; } return add; }
+			var add = counter();
+			add(2); print add(3);`, want: "5\n"},
+		{name: "fields", source: `
+			class P { init() { this.x = 1; } bump() { this.x += 10; } }
+			var p = P(); p.bump(); p.x *= 3; print p.x;`, want: "33\n"},
+		{name: "nested fields", source: `class Box {} var b = Box(); b.inner = Box(); b.inner.n = 1; b.inner.n += 1; print b.inner.n;`, want: "2\n"},
+		{name: "type error", source: `var a = "x"; a -= 1;`, err: "Operands must be numbers."},
+		{name: "undefined", source: `missing += 1;`, err: "Undefined variable 'missing'."},
+		{name: "invalid target", source: `1 += 2;`, err: "line 1 at '+=': Invalid assignment target."},
+		{name: "object with side effects", source: `class Box {} fun make() { return Box(); } make().n += 1;`,
+			err: "line 1 at '+=': Can't use '+=' on a field of this object, which would be evaluated twice; store it in a variable first."},
+	})
+}
+
+func TestIncrement(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "prefix and postfix", source: `var i = 5; print i++; print i; print ++i; print i--; print --i; print i;`, want: "5\n6\n7\n7\n5\n5\n"},
+		{name: "in loops", source: `for (var i = 0; i < 3; i++) print i;`, want: "0\n1\n2\n"},
+		{name: "locals and closures", source: `fun counter() { var n = 0; fun next() { return ++n; } return next; } var c = counter(); c(); print c();`, want: "2\n"},
+		{name: "fields", source: `class C { init() { this.n = 0; } tick() { return this.n++; } } var c = C(); c.tick(); print c.tick(); print c.n;`, want: "1\n2\n"},
+		{name: "object evaluated once", source: `
+			class Box {} var box = Box(); box.n = 1; var calls = 0;
+			fun get() { calls++; return box; }
+			get().n++; print box.n; print calls;`, want: "2\n1\n"},
+		{name: "binds tighter than binary", source: `var a = 1; print a++ + ++a; print -a--; print a;`, want: "4\n-3\n2\n"},
+		{name: "not a number", source: `var s = "a"; s++;`, err: "Operand must be a number."},
+		{name: "undefined", source: `missing--;`, err: "Undefined variable 'missing'."},
+		{name: "invalid target", source: `var a = 1; (a + 1)++;`, err: "line 1 at '++': Invalid '++' target: expected a variable or field."},
+		{name: "literal target", source: `--1;`, err: "line 1 at '--': Invalid '--' target: expected a variable or field."},
+	})
+}
+
+func TestAnonymousFunctions(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "called directly", source: `print fun (a, b) { return a * b; }(3, 4);`, want: "12\n"},
+		{name: "stored", source: `var square = fun (n) { return n * n; }; print square(5); print square;`, want: "25\n<fn>\n"},
+		{name: "passed as argument", source: `
+			fun twice(f, x) { return f(f(x)); }
+			print twice(fun (n) { return n + 3; }, 1);`, want: "7\n"},
+		{name: "closure", source: `
+			fun adder(k) { return fun (n) { return n + k; }; }
+			var add2 = adder(2);
+			print add2(40);`, want: "42\n"},
+		{name: "no parameters", source: `var f = fun () { print "ran"; }; f();`, want: "ran\n"},
+		{name: "method field", source: `class A {} var a = A(); a.greet = fun (name) { return "hi " + name; }; print a.greet("bo");`, want: "hi bo\n"},
+		{name: "named declaration still works", source: `fun named() { return "named"; } print named();`, want: "named\n"},
+		{name: "runtime error", source: `var f = fun () { return nil + 1; }; f();`, err: "Operands must be two numbers or two strings."},
+		{name: "top-level return inside", source: `var f = fun () { return 1; }; return f;`, err: "line 1 at 'return': Can't return from top-level code."},
+		{name: "missing parenthesis", source: `var f = fun { };`, err: "line 1 at '{': Expect '(' after 'fun'."},
+	})
+}
+
+func TestArrowFunctions(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "implicit return", source: `var add = (a, b) => a + b; print add(1, 2);`, want: "3\n"},
+		{name: "one and no parameters", source: `var double = (n) => n * 2; var zero = () => 0; print double(4); print zero();`, want: "8\n0\n"},
+		{name: "body is a whole expression", source: `var pick = (c) => c ? "yes" : "no"; var set = (o) => o.v = 1; print pick(true);`, want: "yes\n"},
+		{name: "with natives", source: `print sort(list(3, 1, 2), (a, b) => a > b);`, want: "[3, 2, 1]\n"},
+		{name: "closure", source: `fun adder(k) { return (n) => n + k; } print adder(2)(3);`, want: "5\n"},
+		{name: "curried", source: `var add = (a) => (b) => a + b; print add(1)(2);`, want: "3\n"},
+		{name: "grouping is not an arrow", source: `var a = 1; print (a) + 1; print (a);`, want: "2\n1\n"},
+		{name: "empty parentheses", source: `print ();`, err: "line 1 at ')': Expect expression."},
+		{name: "block body", source: `var f = () => { return 1; };`, err: "line 1 at '{': Expect expression."},
+		{name: "bad parameter", source: `var f = (1) => 1;`, err: "line 1 at '=>': Expect ';' after variable declaration."},
+	})
+}
+
+var loopBenchmarks = []struct{ name, source string }{
+	{"Arithmetic", `
+		var sum = 0;
+		var i = 0;
+		while (i < 100000) {
+			sum = sum + i * (60 * 60 * 24) / (1000 * 1000);
+			i = i + 1;
+		}`},
+	{"Globals", `
+		var total = 0;
+		fun add(n) { total = total + n; }
+		for (var i = 0; i < 100000; i = i + 1) {
+			add(i);
+		}`},
+	{"Methods", `
+		class Base { step() { return 1; } }
+		class Counter < Base { init() { this.n = 0; } tick() { this.n = this.n + this.step(); } }
+		var c = Counter();
+		for (var i = 0; i < 100000; i = i + 1) {
+			c.tick();
+		}`},
+	{"InheritedMethods", `
+		class A { get() { return 1; } }
+		class B < A {} class C < B {} class D < C {} class E < D {}
+		var e = E();
+		var sum = 0;
+		for (var i = 0; i < 100000; i = i + 1) {
+			sum = sum + e.get();
+		}`},
+	{"GlobalReads", `
+		var a = 1; var b = 2; var c = 3;
+		var sum = 0;
+		var i = 0;
+		while (i < 100000) {
+			sum = sum + a + b + c;
+			i = i + 1;
+		}`},
+}
+
+func BenchmarkLoops(b *testing.B) {
+	for _, bench := range loopBenchmarks {
+		b.Run(bench.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				_, run := prepare(b, bench.source)
+				if err := run(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultMaxDepth(t *testing.T) {
+	_, run := prepare(t, `fun f(n) { return f(n + 1); } f(0);`)
+	err := run()
+	rt, ok := err.(*RuntimeError)
+	if !ok || rt.Message != "Stack overflow." {
+		t.Fatalf("got %v, want a stack overflow", err)
+	}
+	if len(rt.Trace) != DefaultMaxDepth {
+		t.Errorf("got %d frames, want %d", len(rt.Trace), DefaultMaxDepth)
+	}
+}
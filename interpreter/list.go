@@ -0,0 +1,228 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// LoxList is an ordered, growable list of values. Lox has no list syntax;
+// lists are made by natives such as list(...) and used through their
+// properties:
+//
+//	list.length        the number of elements
+//	list.get(i)        the element at index i, counting from 0
+//	list.set(i, value) replace the element at index i
+//	list.push(value)   append value
+//	list.pop()         remove and return the last element
+type LoxList struct {
+	elements []any
+	// version counts changes, so that sort can tell if its comparator
+	// changed the list.
+	version int
+}
+
+// NewList returns a list holding elements, which it takes ownership of.
+func NewList(elements []any) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+// Elements returns the list's elements. The slice is shared with the list
+// until the list next grows.
+func (l *LoxList) Elements() []any {
+	return l.elements
+}
+
+func (l *LoxList) Get(name string) (any, error) {
+	switch name {
+	case "length":
+		return float64(len(l.elements)), nil
+	case "get":
+		return &nativeFunction{name: "get", arity: 1, fn: func(_ *Interpreter, args []any) (any, error) {
+			i, err := l.index(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return l.elements[i], nil
+		}}, nil
+	case "set":
+		return &nativeFunction{name: "set", arity: 2, fn: func(_ *Interpreter, args []any) (any, error) {
+			i, err := l.index(args[0])
+			if err != nil {
+				return nil, err
+			}
+			l.elements[i] = args[1]
+			l.version++
+			return nil, nil
+		}}, nil
+	case "push":
+		return &nativeFunction{name: "push", arity: 1, fn: func(_ *Interpreter, args []any) (any, error) {
+			l.elements = append(l.elements, args[0])
+			l.version++
+			return nil, nil
+		}}, nil
+	case "pop":
+		return &nativeFunction{name: "pop", arity: 0, fn: func(_ *Interpreter, _ []any) (any, error) {
+			if len(l.elements) == 0 {
+				return nil, errors.New("Can't pop from an empty list.")
+			}
+			last := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			l.version++
+			return last, nil
+		}}, nil
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (l *LoxList) Set(name string, _ any) error {
+	return fmt.Errorf("Can't assign property '%s' of a list.", name)
+}
+
+// index checks that value is a valid index into the list.
+func (l *LoxList) index(value any) (int, error) {
+	f, ok := toNumber(value)
+	if !ok || f != math.Trunc(f) {
+		return 0, errors.New("List index must be an integer.")
+	}
+	if f < 0 || f >= float64(len(l.elements)) {
+		return 0, fmt.Errorf("List index %s is out of range.", Stringify(f))
+	}
+	return int(f), nil
+}
+
+func (l *LoxList) String() string {
+	return l.format(map[any]bool{})
+}
+
+// format writes out the list, which may contain itself; printing holds
+// the lists and maps being written out further up, and a list among them
+// prints as "[...]".
+func (l *LoxList) format(printing map[any]bool) string {
+	if printing[l] {
+		return "[...]"
+	}
+	printing[l] = true
+	defer delete(printing, l)
+	parts := make([]string, len(l.elements))
+	for i, element := range l.elements {
+		parts[i] = stringify(element, printing)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// stringify is Stringify for a value inside a list or map, which may be
+// one of the lists or maps in printing.
+func stringify(value any, printing map[any]bool) string {
+	switch v := value.(type) {
+	case *LoxList:
+		return v.format(printing)
+	case *LoxMap:
+		return v.format(printing)
+	}
+	return Stringify(value)
+}
+
+// defineListNatives defines the natives that make and rearrange lists:
+//
+//	list(values...)  a new list of the arguments
+//	sort(list)       sort numbers or strings in ascending order
+//	sort(list, fn)   sort by a comparator: fn(a, b) returns true, or a
+//	                 negative number, when a belongs before b
+//
+// sort is stable, works in place and returns the list.
+func defineListNatives(globals *Environment) {
+	globals.define("list", &nativeFunction{name: "list", arity: -1, fn: func(_ *Interpreter, args []any) (any, error) {
+		return NewList(append([]any(nil), args...)), nil
+	}})
+	globals.define("sort", &nativeFunction{name: "sort", arity: -1, fn: func(i *Interpreter, args []any) (any, error) {
+		if len(args) != 1 && len(args) != 2 {
+			return nil, fmt.Errorf("Expected 1 or 2 arguments but got %d.", len(args))
+		}
+		l, ok := args[0].(*LoxList)
+		if !ok {
+			return nil, errors.New("Can only sort lists.")
+		}
+		less := naturalLess
+		if len(args) == 2 {
+			comparator, ok := args[1].(LoxCallable)
+			if !ok {
+				return nil, errors.New("Comparator must be a function.")
+			}
+			less = func(a, b any) (bool, error) { return i.compare(comparator, a, b) }
+		}
+		return l, l.sort(less)
+	}})
+}
+
+// sortAbort unwinds sort.SliceStable when a comparison fails.
+type sortAbort struct{ err error }
+
+// sort sorts the list stably by less. The elements are sorted as a copy,
+// which replaces them only once every comparison has succeeded, so a
+// comparator that fails leaves the list as it was, and one that changes
+// the list is reported rather than sorting a moving target.
+func (l *LoxList) sort(less func(a, b any) (bool, error)) (err error) {
+	sorted := append([]any(nil), l.elements...)
+	version := l.version
+	defer func() {
+		if r := recover(); r != nil {
+			abort, ok := r.(sortAbort)
+			if !ok {
+				panic(r)
+			}
+			err = abort.err
+		}
+	}()
+	sort.SliceStable(sorted, func(a, b int) bool {
+		result, err := less(sorted[a], sorted[b])
+		if err == nil && l.version != version {
+			err = errors.New("List was changed by the comparator while being sorted.")
+		}
+		if err != nil {
+			panic(sortAbort{err})
+		}
+		return result
+	})
+	l.elements = sorted
+	l.version++
+	return nil
+}
+
+// compare calls a sort comparator, which may answer with a boolean or,
+// as in JavaScript, with a number that is negative when a comes first.
+func (i *Interpreter) compare(comparator LoxCallable, a, b any) (bool, error) {
+	result, err := i.Call(comparator, []any{a, b})
+	if err != nil {
+		return false, err
+	}
+	if less, ok := result.(bool); ok {
+		return less, nil
+	}
+	if n, ok := toNumber(result); ok {
+		return n < 0, nil
+	}
+	return false, fmt.Errorf("Comparator must return a boolean or a number, not %s.", Stringify(result))
+}
+
+// naturalLess orders numbers numerically and strings by their bytes.
+func naturalLess(a, b any) (bool, error) {
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return x < y, nil
+		}
+	}
+	if less, ok := bigBinary(token.Less, a, b); ok {
+		return less.(bool), nil
+	}
+	x, xok := toNumber(a)
+	y, yok := toNumber(b)
+	if !xok || !yok {
+		return false, errors.New("Without a comparator, sort can only compare two numbers or two strings.")
+	}
+	return x < y, nil
+}
@@ -0,0 +1,63 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+func TestListStringCycle(t *testing.T) {
+	for source, want := range map[string]string{
+		`var l = list(1, 2); l.push(l); print l;`:              "[1, 2, [...]]\n",
+		`var a = list(); var b = list(a); a.push(b); print a;`: "[[[...]]]\n",
+		`var a = list(1); print list(a, a);`:                   "[[1], [1]]\n",
+	} {
+		if got := output(t, source); got != want {
+			t.Errorf("%s: got %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "natural order", source: `print sort(list(3, 1, 2)); print sort(list("b", "a", "c"));`, want: "[1, 2, 3]\n[a, b, c]\n"},
+		{name: "in place", source: `var l = list(2, 1); sort(l); print l;`, want: "[1, 2]\n"},
+		{name: "numeric comparator", source: `print sort(list(1, 3, 2), fun (a, b) { return b - a; });`, want: "[3, 2, 1]\n"},
+		{name: "stable", source: `
+			var people = list();
+			for (var i = 0; i < 20; i++) people.push(list(i % 3, i));
+			sort(people, fun (a, b) { return a.get(0) < b.get(0); });
+			var order = list();
+			for (var i = 0; i < people.length; i++) order.push(people.get(i).get(1));
+			print order;`, want: "[0, 3, 6, 9, 12, 15, 18, 1, 4, 7, 10, 13, 16, 19, 2, 5, 8, 11, 14, 17]\n"},
+		{name: "mixed types", source: `sort(list(1, "a"));`, err: "Without a comparator, sort can only compare two numbers or two strings."},
+		{name: "bad comparator result", source: `sort(list(1, 2), fun (a, b) { return "x"; });`, err: "Comparator must return a boolean or a number, not x."},
+		{name: "not a function", source: `sort(list(1, 2), 3);`, err: "Comparator must be a function."},
+	})
+}
+
+// TestSortFailure checks that a comparator that fails, or changes the list
+// it is sorting, stops the sort with an error. The sorted order is never
+// written back, so the list holds only the comparator's own changes.
+func TestSortFailure(t *testing.T) {
+	for _, test := range []struct{ source, err, list string }{
+		{`sort(l, fun (a, b) { return a < nil; });`, "Operands must be numbers.", "[3, 1, 2]"},
+		{`fun cmp(a, b) { return cmp(a, b); } sort(l, cmp);`, "Stack overflow.", "[3, 1, 2]"},
+		{`sort(l, fun (a, b) { l.push(0); return a < b; });`, "List was changed by the comparator while being sorted.", "[3, 1, 2, 0]"},
+		{`sort(l, fun (a, b) { l.set(0, 9); return a < b; });`, "List was changed by the comparator while being sorted.", "[9, 1, 2]"},
+		{`sort(l, fun (a, b) { sort(l); return a < b; });`, "List was changed by the comparator while being sorted.", "[1, 2, 3]"},
+	} {
+		i, run := prepare(t, `var l = list(3, 1, 2); `+test.source)
+		err := run()
+		if rt, ok := err.(*RuntimeError); !ok || rt.Message != test.err {
+			t.Errorf("%s: got %v, want %q", test.source, err, test.err)
+		}
+		value, err := i.globals.get(token.Token{Lexeme: "l"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Stringify(value); got != test.list {
+			t.Errorf("%s: left the list as %s, want %s", test.source, got, test.list)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// LoxMap is a hash map from keys to values that remembers the order keys
+// were first added in. Keys are strings, numbers, booleans or nil. Like
+// lists, maps are made by a native, map(key, value, ...), and used through
+// their properties:
+//
+//	map.length           the number of entries
+//	map.get(key)         the value for key, or nil if there is none
+//	map.set(key, value)  add or replace the entry for key
+//	map.has(key)         whether there is an entry for key
+//	map.remove(key)      remove the entry for key and return its value
+//	map.keys()           a list of the keys, in insertion order
+type LoxMap struct {
+	order   []any
+	entries map[any]mapEntry
+}
+
+type mapEntry struct {
+	key, value any
+}
+
+// bigKey stands in for an integer too large for a float64, since two
+// *big.Int holding the same value are different Go map keys.
+type bigKey string
+
+// NewMap returns an empty map.
+func NewMap() *LoxMap {
+	return &LoxMap{entries: map[any]mapEntry{}}
+}
+
+// mapKey returns the Go map key that the Lox value key is stored under.
+func mapKey(key any) (any, error) {
+	switch k := key.(type) {
+	case nil, string, float64, bool:
+		return k, nil
+	case *big.Int:
+		return bigKey(k.String()), nil
+	}
+	return nil, errors.New("Map keys must be strings, numbers, booleans or nil.")
+}
+
+// Lookup returns the value for key, and whether there is one.
+func (m *LoxMap) Lookup(key any) (any, bool) {
+	k, err := mapKey(key)
+	if err != nil {
+		return nil, false
+	}
+	entry, ok := m.entries[k]
+	return entry.value, ok
+}
+
+// Store adds or replaces the entry for key.
+func (m *LoxMap) Store(key, value any) error {
+	k, err := mapKey(key)
+	if err != nil {
+		return err
+	}
+	if _, ok := m.entries[k]; !ok {
+		m.order = append(m.order, k)
+	}
+	m.entries[k] = mapEntry{key, value}
+	return nil
+}
+
+// Keys returns the map's keys in insertion order.
+func (m *LoxMap) Keys() []any {
+	keys := make([]any, len(m.order))
+	for n, k := range m.order {
+		keys[n] = m.entries[k].key
+	}
+	return keys
+}
+
+func (m *LoxMap) Get(name string) (any, error) {
+	switch name {
+	case "length":
+		return float64(len(m.order)), nil
+	case "get":
+		return &nativeFunction{name: "get", arity: 1, fn: func(_ *Interpreter, args []any) (any, error) {
+			if _, err := mapKey(args[0]); err != nil {
+				return nil, err
+			}
+			value, _ := m.Lookup(args[0])
+			return value, nil
+		}}, nil
+	case "set":
+		return &nativeFunction{name: "set", arity: 2, fn: func(_ *Interpreter, args []any) (any, error) {
+			return nil, m.Store(args[0], args[1])
+		}}, nil
+	case "has":
+		return &nativeFunction{name: "has", arity: 1, fn: func(_ *Interpreter, args []any) (any, error) {
+			if _, err := mapKey(args[0]); err != nil {
+				return nil, err
+			}
+			_, ok := m.Lookup(args[0])
+			return ok, nil
+		}}, nil
+	case "remove":
+		return &nativeFunction{name: "remove", arity: 1, fn: func(_ *Interpreter, args []any) (any, error) {
+			k, err := mapKey(args[0])
+			if err != nil {
+				return nil, err
+			}
+			entry, ok := m.entries[k]
+			if !ok {
+				return nil, nil
+			}
+			delete(m.entries, k)
+			m.order = slices.DeleteFunc(m.order, func(other any) bool { return other == k })
+			return entry.value, nil
+		}}, nil
+	case "keys":
+		return &nativeFunction{name: "keys", arity: 0, fn: func(_ *Interpreter, _ []any) (any, error) {
+			return NewList(m.Keys()), nil
+		}}, nil
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (m *LoxMap) Set(name string, _ any) error {
+	return fmt.Errorf("Can't assign property '%s' of a map.", name)
+}
+
+func (m *LoxMap) String() string {
+	return m.format(map[any]bool{})
+}
+
+// format writes out the map as LoxList.format writes out a list, printing
+// a map that contains itself, directly or through lists, as "{...}".
+func (m *LoxMap) format(printing map[any]bool) string {
+	if printing[m] {
+		return "{...}"
+	}
+	printing[m] = true
+	defer delete(printing, m)
+	parts := make([]string, len(m.order))
+	for n, k := range m.order {
+		entry := m.entries[k]
+		parts[n] = Stringify(entry.key) + ": " + stringify(entry.value, printing)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// defineMapNatives defines map(key, value, ...), which makes a map of the
+// pairs it is passed.
+func defineMapNatives(globals *Environment) {
+	globals.define("map", &nativeFunction{name: "map", arity: -1, fn: func(_ *Interpreter, args []any) (any, error) {
+		if len(args)%2 != 0 {
+			return nil, errors.New("Expected keys and values in pairs.")
+		}
+		m := NewMap()
+		for n := 0; n < len(args); n += 2 {
+			if err := m.Store(args[n], args[n+1]); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}})
+}
@@ -0,0 +1,16 @@
+package interpreter
+
+import "testing"
+
+func TestMapStringCycle(t *testing.T) {
+	for source, want := range map[string]string{
+		`var m = map(); m.set("self", m); print m;`:                       "{self: {...}}\n",
+		`var m = map(); m.set("l", list(1, m)); print m;`:                 "{l: [1, {...}]}\n",
+		`var l = list(); var m = map(); m.set(1, l); l.push(m); print l;`: "[{1: [...]}]\n",
+		`var m = map(); m.set("a", 1); print list(m, m);`:                 "[{a: 1}, {a: 1}]\n",
+	} {
+		if got := output(t, source); got != want {
+			t.Errorf("%s: got %q, want %q", source, got, want)
+		}
+	}
+}
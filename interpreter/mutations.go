@@ -0,0 +1,95 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+)
+
+// MutationKind says how a variable changed.
+type MutationKind int
+
+const (
+	Defined MutationKind = iota
+	Assigned
+)
+
+// Mutation records one change to a variable.
+type Mutation struct {
+	Kind MutationKind
+	Name string
+	// Old is the value before an assignment; it is always nil for a
+	// definition.
+	Old, New any
+	File     string
+	Line     int
+}
+
+func (m Mutation) String() string {
+	location := fmt.Sprintf("line %d", m.Line)
+	if m.File != "" {
+		location += " in " + m.File
+	}
+	if m.Kind == Defined {
+		return fmt.Sprintf("[%s] var %s = %s", location, m.Name, Stringify(m.New))
+	}
+	return fmt.Sprintf("[%s] %s: %s -> %s", location, m.Name, Stringify(m.Old), Stringify(m.New))
+}
+
+// MutationLog keeps the most recent variable definitions and assignments
+// in a ring buffer, so the history leading up to a bad value can be
+// inspected without the cost of an unbounded trace.
+type MutationLog struct {
+	entries []Mutation
+	next    int
+	full    bool
+}
+
+// NewMutationLog returns a log holding the last size mutations.
+func NewMutationLog(size int) *MutationLog {
+	return &MutationLog{entries: make([]Mutation, size)}
+}
+
+func (l *MutationLog) record(m Mutation) {
+	if len(l.entries) == 0 {
+		return
+	}
+	// Strict mode's placeholder for a missing initializer reads as nil.
+	if _, ok := m.Old.(unassigned); ok {
+		m.Old = nil
+	}
+	if _, ok := m.New.(unassigned); ok {
+		m.New = nil
+	}
+	l.entries[l.next] = m
+	l.next++
+	if l.next == len(l.entries) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// Entries returns the logged mutations, oldest first.
+func (l *MutationLog) Entries() []Mutation {
+	if !l.full {
+		return append([]Mutation(nil), l.entries[:l.next]...)
+	}
+	return append(append([]Mutation(nil), l.entries[l.next:]...), l.entries[:l.next]...)
+}
+
+// Of returns the logged mutations of the variable name, oldest first.
+func (l *MutationLog) Of(name string) []Mutation {
+	var matches []Mutation
+	for _, m := range l.Entries() {
+		if m.Name == name {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// Dump writes the logged mutations to w, one per line, oldest first.
+func (l *MutationLog) Dump(w io.Writer) {
+	for _, m := range l.Entries() {
+		fmt.Fprintln(w, m)
+	}
+}
@@ -0,0 +1,357 @@
+package interpreter
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// dialTimeout bounds how long tcpConnect and wsConnect wait for the other
+// end to answer.
+const dialTimeout = 10 * time.Second
+
+// EnableNetwork defines the socket natives. They are left out unless the
+// embedder asks for them, since they let a script reach any host the
+// process can:
+//
+//	tcpConnect(host, port)  open a TCP connection; returns a socket
+//	wsConnect(url)          open a WebSocket (ws:// or wss://) connection
+//	send(socket, text)      write text; on a WebSocket, as one text message
+//	recvLine(socket)        the next line, without its line ending, or the
+//	                        next WebSocket message; nil once the peer closes
+//	onReceive(socket, fn)   have runLoop call fn(line) for every line or
+//	                        message as it arrives
+//	close(socket)           close the connection
+func (i *Interpreter) EnableNetwork() {
+	i.defineCapability("net", "tcpConnect", 2, func(_ *Interpreter, args []any) (any, error) {
+		host, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("Host must be a string.")
+		}
+		port, ok := toNumber(args[1])
+		if !ok {
+			return nil, errors.New("Port must be a number.")
+		}
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, fmt.Sprint(port)), dialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("Can't connect: %v.", err)
+		}
+		return &socket{conn: conn, r: bufio.NewReader(conn), name: conn.RemoteAddr().String()}, nil
+	})
+	i.defineCapability("net", "wsConnect", 1, func(_ *Interpreter, args []any) (any, error) {
+		rawURL, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("URL must be a string.")
+		}
+		s, err := dialWebSocket(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("Can't connect: %v.", err)
+		}
+		return s, nil
+	})
+	i.defineCapability("net", "send", 2, func(_ *Interpreter, args []any) (any, error) {
+		s, err := asSocket(args[0])
+		if err != nil {
+			return nil, err
+		}
+		text, ok := args[1].(string)
+		if !ok {
+			return nil, errors.New("Can only send strings.")
+		}
+		if err := s.send(text); err != nil {
+			return nil, fmt.Errorf("Can't send: %v.", err)
+		}
+		return nil, nil
+	})
+	i.defineCapability("net", "recvLine", 1, func(_ *Interpreter, args []any) (any, error) {
+		s, err := asSocket(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if s.listening {
+			return nil, errors.New("Socket is already being read by onReceive.")
+		}
+		return s.receive()
+	})
+	i.defineCapability("net", "onReceive", 2, func(i *Interpreter, args []any) (any, error) {
+		s, err := asSocket(args[0])
+		if err != nil {
+			return nil, err
+		}
+		callback, ok := args[1].(LoxCallable)
+		if !ok {
+			return nil, errors.New("Receive callback must be a function.")
+		}
+		if s.listening {
+			return nil, errors.New("Socket is already being read by onReceive.")
+		}
+		s.listening = true
+		inbox := i.events.startSource()
+		go func() {
+			for {
+				message, err := s.receive()
+				if err != nil || message == nil {
+					inbox <- delivery{}
+					return
+				}
+				inbox <- delivery{callback: callback, argument: message}
+			}
+		}()
+		return nil, nil
+	})
+	i.defineCapability("net", "close", 1, func(_ *Interpreter, args []any) (any, error) {
+		s, err := asSocket(args[0])
+		if err != nil {
+			return nil, err
+		}
+		s.close()
+		return nil, nil
+	})
+}
+
+// socket is the Lox value for a network connection.
+type socket struct {
+	conn net.Conn
+	r    *bufio.Reader
+	name string
+	// ws is set for WebSocket connections, whose messages are framed
+	// rather than separated by newlines.
+	ws bool
+	// listening is set once onReceive has taken over reading.
+	listening bool
+}
+
+func asSocket(value any) (*socket, error) {
+	if s, ok := value.(*socket); ok {
+		return s, nil
+	}
+	return nil, errors.New("Expected a socket.")
+}
+
+func (s *socket) String() string {
+	return "<socket " + s.name + ">"
+}
+
+func (s *socket) send(text string) error {
+	if s.ws {
+		return s.writeFrame(opText, []byte(text))
+	}
+	_, err := io.WriteString(s.conn, text)
+	return err
+}
+
+// receive returns the next line or message, or nil once the peer has
+// closed the connection.
+func (s *socket) receive() (any, error) {
+	if s.ws {
+		message, err := s.readMessage()
+		if err == io.EOF || errors.Is(err, net.ErrClosed) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Can't receive: %v.", err)
+		}
+		return message, nil
+	}
+
+	line, err := s.r.ReadString('\n')
+	if (err == io.EOF || errors.Is(err, net.ErrClosed)) && line == "" {
+		return nil, nil
+	}
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("Can't receive: %v.", err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func (s *socket) close() {
+	if s.ws {
+		// Best effort: the peer may already be gone.
+		_ = s.writeFrame(opClose, nil)
+	}
+	_ = s.conn.Close()
+}
+
+// WebSocket opcodes, from RFC 6455.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// WebSocket frames carry their length, which readMessage checks against
+// these limits before allocating anything, so that a peer can't make the
+// interpreter run out of memory by claiming a huge frame.
+const (
+	maxFrameSize   = 16 << 20
+	maxMessageSize = 64 << 20
+)
+
+// websocketGUID is appended to the handshake key to compute the server's
+// accept token.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// dialWebSocket connects to a ws:// or wss:// URL and performs the
+// opening handshake.
+func dialWebSocket(rawURL string) (*socket, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), map[string]string{"ws": "80", "wss": "443"}[u.Scheme])
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		conn, err = dialer.Dial("tcp", host)
+	case "wss":
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q, expected ws or wss", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	request := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{
+		"Upgrade":               {"websocket"},
+		"Connection":            {"Upgrade"},
+		"Sec-WebSocket-Key":     {key},
+		"Sec-WebSocket-Version": {"13"},
+	}}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	response, err := http.ReadResponse(r, request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	if response.StatusCode != http.StatusSwitchingProtocols ||
+		response.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("handshake refused: %s", response.Status)
+	}
+	return &socket{conn: conn, r: r, name: rawURL, ws: true}, nil
+}
+
+// writeFrame sends one final frame. Frames from a client must be masked.
+func (s *socket) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, 0x80|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 0x80|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	header = append(header, mask...)
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+	_, err := s.conn.Write(append(header, masked...))
+	return err
+}
+
+// readMessage returns the next text or binary message, joining fragmented
+// frames and answering pings on the way. A close frame ends the stream
+// with io.EOF.
+func (s *socket) readMessage() (string, error) {
+	var message []byte
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(s.r, head[:]); err != nil {
+			return "", err
+		}
+		final, opcode := head[0]&0x80 != 0, head[0]&0x0F
+		length := uint64(head[1] & 0x7F)
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(s.r, ext[:]); err != nil {
+				return "", err
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(s.r, ext[:]); err != nil {
+				return "", err
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		if length > maxFrameSize {
+			return "", fmt.Errorf("frame of %d bytes exceeds the limit of %d", length, maxFrameSize)
+		}
+		if uint64(len(message))+length > maxMessageSize {
+			return "", fmt.Errorf("message exceeds the limit of %d bytes", maxMessageSize)
+		}
+		var mask [4]byte
+		masked := head[1]&0x80 != 0
+		if masked {
+			if _, err := io.ReadFull(s.r, mask[:]); err != nil {
+				return "", err
+			}
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(s.r, payload); err != nil {
+			return "", err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch opcode {
+		case opPing:
+			if err := s.writeFrame(opPong, payload); err != nil {
+				return "", err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return "", io.EOF
+		case opText, opBinary, opContinuation:
+			message = append(message, payload...)
+		}
+		if final {
+			return string(message), nil
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package interpreter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// frameHeader is the header of an unmasked WebSocket frame whose payload
+// is length bytes, with the 64-bit length form.
+func frameHeader(final bool, opcode byte, length uint64) []byte {
+	if final {
+		opcode |= 0x80
+	}
+	return binary.BigEndian.AppendUint64([]byte{opcode, 127}, length)
+}
+
+func TestReadMessage(t *testing.T) {
+	var stream []byte
+	stream = append(stream, frameHeader(false, opText, 3)...)
+	stream = append(stream, "abc"...)
+	stream = append(stream, frameHeader(true, opContinuation, 2)...)
+	stream = append(stream, "de"...)
+	s := &socket{r: bufio.NewReader(bytes.NewReader(stream)), ws: true}
+	if got, err := s.readMessage(); err != nil || got != "abcde" {
+		t.Errorf("got %q, %v, want %q", got, err, "abcde")
+	}
+}
+
+func TestReadMessageLimits(t *testing.T) {
+	// Only headers are sent: readMessage must refuse before it reads, or
+	// allocates room for, the payload.
+	huge := frameHeader(true, opText, 1<<62)
+	var fragments []byte
+	for n := 0; n*maxFrameSize <= maxMessageSize; n++ {
+		fragments = append(fragments, frameHeader(false, opText, maxFrameSize)...)
+		fragments = append(fragments, make([]byte, maxFrameSize)...)
+	}
+	for name, test := range map[string]struct {
+		stream []byte
+		want   string
+	}{
+		"frame":   {huge, "frame of"},
+		"message": {fragments, "message exceeds"},
+	} {
+		s := &socket{r: bufio.NewReader(bytes.NewReader(test.stream)), ws: true}
+		if _, err := s.readMessage(); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want an error about the %s size", name, err, name)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// defineParallelNatives defines pmap(list, fn), which calls fn on every
+// element of list across several goroutines and returns a list of the
+// results, in the order of the elements.
+//
+// The interpreter can't be shared between goroutines, so each call gets a
+// sandbox: an interpreter of its own, working on its own copy of
+// everything fn can reach. Every call starts from the heap as it was when
+// pmap was called, so no call sees what another changed. Whatever fn
+// changes outside its result, whether variables it closes over or objects
+// it is passed, stays in the sandbox and is lost when the call returns. What
+// fn prints is held back and written out in the order of the elements
+// once every call is done. Timers and events fn starts are dropped with
+// its sandbox; native objects such as sockets are not copied and are best
+// left alone.
+func defineParallelNatives(globals *Environment) {
+	globals.define("pmap", &nativeFunction{name: "pmap", arity: 2, fn: func(i *Interpreter, args []any) (any, error) {
+		l, ok := args[0].(*LoxList)
+		if !ok {
+			return nil, errors.New("Can only pmap over a list.")
+		}
+		fn, ok := args[1].(LoxCallable)
+		if !ok {
+			return nil, errors.New("Expected a function to call on each element.")
+		}
+		return i.pmap(l.elements, fn)
+	}})
+}
+
+// pmapResult is what one call made by pmap produced.
+type pmapResult struct {
+	value  any
+	output []byte
+	err    error
+}
+
+func (i *Interpreter) pmap(elements []any, fn LoxCallable) (any, error) {
+	elements = append([]any(nil), elements...)
+	results := make([]pmapResult, len(elements))
+
+	// The workers only bound how many calls run at once. Each call copies
+	// the heap afresh; nothing writes to the original while pmap runs, so
+	// the workers can all read it.
+	var next atomic.Int64
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(elements)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				n := int(next.Add(1) - 1)
+				if n >= len(elements) {
+					return
+				}
+				s := i.newSandbox(fn)
+				var output bytes.Buffer
+				s.interpreter.stdout = &output
+				value, err := s.interpreter.Call(s.fn, []any{s.copy(elements[n])})
+				results[n] = pmapResult{value: s.restore(value), output: output.Bytes(), err: err}
+				if err != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Merge: print what each call printed, in order, up to the first call
+	// that failed.
+	values := make([]any, len(results))
+	for n, result := range results {
+		if _, err := i.stdout.Write(result.output); err != nil {
+			return nil, err
+		}
+		if result.err != nil {
+			return nil, result.err
+		}
+		values[n] = result.value
+	}
+	return NewList(values), nil
+}
+
+// sandbox is a copy of the interpreter and the part of its heap that a
+// function can reach, so that the function can run on another goroutine.
+type sandbox struct {
+	interpreter *Interpreter
+	fn          LoxCallable
+	// copies maps each original environment, function, class, list, map
+	// and instance to its copy, and originals maps the copied functions
+	// and classes back.
+	copies    map[any]any
+	originals map[any]any
+}
+
+func (i *Interpreter) newSandbox(fn LoxCallable) *sandbox {
+	s := newHeapCopy()
+	s.interpreter = &Interpreter{
+		BigInt:   i.BigInt,
+		Strict:   i.Strict,
+		MaxDepth: i.MaxDepth,
+		Audit:    i.Audit,
+		globals:  s.environment(i.globals),
+		callSite: i.callSite,
+		// The resolver only writes locals while resolving new code, which
+		// can't happen while pmap runs, so the workers can share it.
+		locals: i.locals,
+	}
+	s.interpreter.environment = s.interpreter.globals
+	s.fn = s.copy(fn).(LoxCallable)
+	return s
+}
+
+// newHeapCopy returns a sandbox with nothing copied into it yet and no
+// interpreter, for copying environments and values.
+func newHeapCopy() *sandbox {
+	return &sandbox{copies: map[any]any{}, originals: map[any]any{}}
+}
+
+func (s *sandbox) environment(e *Environment) *Environment {
+	if e == nil {
+		return nil
+	}
+	if copied, ok := s.copies[e]; ok {
+		return copied.(*Environment)
+	}
+	env := &Environment{values: make(map[string]any, len(e.values))}
+	s.copies[e] = env
+	env.enclosing = s.environment(e.enclosing)
+	for name, value := range e.values {
+		env.values[name] = s.copy(value)
+	}
+	return env
+}
+
+// copy copies value into the sandbox. Values that can't change, and
+// natives, are shared.
+func (s *sandbox) copy(value any) any {
+	if copied, ok := s.copies[value]; ok {
+		return copied
+	}
+	switch v := value.(type) {
+	case *LoxFunction:
+		f := &LoxFunction{declaration: v.declaration, isInitializer: v.isInitializer}
+		s.copies[v], s.originals[f] = f, v
+		f.closure = s.environment(v.closure)
+		return f
+	case *LoxClass:
+		c := &LoxClass{name: v.name, methods: make(map[string]*LoxFunction, len(v.methods))}
+		s.copies[v], s.originals[c] = c, v
+		if v.superclass != nil {
+			c.superclass = s.copy(v.superclass).(*LoxClass)
+		}
+		for name, method := range v.methods {
+			c.methods[name] = s.copy(method).(*LoxFunction)
+		}
+		return c
+	case *LoxList:
+		l := NewList(make([]any, len(v.elements)))
+		s.copies[v] = l
+		for n, element := range v.elements {
+			l.elements[n] = s.copy(element)
+		}
+		return l
+	case *LoxMap:
+		m := NewMap()
+		s.copies[v] = m
+		m.order = append(m.order, v.order...)
+		for k, entry := range v.entries {
+			m.entries[k] = mapEntry{entry.key, s.copy(entry.value)}
+		}
+		return m
+	case *LoxInstance:
+		in := &LoxInstance{fields: make(map[string]any, len(v.fields))}
+		s.copies[v] = in
+		in.class = s.copy(v.class).(*LoxClass)
+		for name, field := range v.fields {
+			in.fields[name] = s.copy(field)
+		}
+		return in
+	}
+	return value
+}
+
+// restore prepares a result to leave the sandbox by swapping the copies of
+// functions and classes in it for their originals, so that, say, an
+// instance the function made belongs to the caller's class. Lists, maps
+// and instances stay copies: the sandbox is discarded, so they are the
+// caller's to keep.
+func (s *sandbox) restore(value any) any {
+	seen := map[any]bool{}
+	var walk func(any) any
+	walk = func(value any) any {
+		if original, ok := s.originals[value]; ok {
+			return original
+		}
+		switch v := value.(type) {
+		case *LoxList, *LoxMap, *LoxInstance:
+			if seen[v] {
+				return value
+			}
+			seen[v] = true
+		}
+		switch v := value.(type) {
+		case *LoxList:
+			for n, element := range v.elements {
+				v.elements[n] = walk(element)
+			}
+		case *LoxMap:
+			for k, entry := range v.entries {
+				v.entries[k] = mapEntry{entry.key, walk(entry.value)}
+			}
+		case *LoxInstance:
+			v.class = walk(v.class).(*LoxClass)
+			for name, field := range v.fields {
+				v.fields[name] = walk(field)
+			}
+		}
+		return value
+	}
+	return walk(value)
+}
@@ -0,0 +1,48 @@
+package interpreter
+
+import "testing"
+
+func TestPmap(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "results in input order", source: `
+			var l = list();
+			for (var i = 0; i < 40; i++) l.push(i);
+			// Early elements take longest, so they finish last.
+			var squares = pmap(l, fun (n) {
+				var s = 0;
+				for (var i = 0; i < (40 - n) * 200; i++) s += 1;
+				return n * n;
+			});
+			print squares.length; print squares.get(0); print squares.get(7); print squares.get(39);`,
+			want: "40\n0\n49\n1521\n"},
+		{name: "output in input order", source: `pmap(list(1, 2, 3, 4), fun (n) { print n; print -n; });`, want: "1\n-1\n2\n-2\n3\n-3\n4\n-4\n"},
+		{name: "captured variables are copies", source: `
+			var total = 0; var seen = list(); var m = map();
+			var results = pmap(list(1, 2, 3), fun (n) { total += n; seen.push(n); m.set(n, n); return n; });
+			print total; print seen; print m; print results;`, want: "0\n[]\n{}\n[1, 2, 3]\n"},
+		{name: "each call starts afresh", source: `
+			var c = 0;
+			var l = list();
+			for (var i = 0; i < 50; i++) l.push(i);
+			var results = pmap(l, fun (n) { c += 1; return c; });
+			var ones = 0;
+			for (var i = 0; i < results.length; i++) if (results.get(i) == 1) ones++;
+			print ones; print c;`, want: "50\n0\n"},
+		{name: "arguments are copies", source: `
+			var items = list(list(1), list(2));
+			var results = pmap(items, fun (l) { l.push(0); return l; });
+			print items; print results; print results.get(0) == items.get(0);`,
+			want: "[[1], [2]]\n[[1, 0], [2, 0]]\nfalse\n"},
+		{name: "functions and classes come back as themselves", source: `
+			fun id(x) { return x; }
+			class P { init(x) { this.x = x; } twice() { return this.x * 2; } }
+			var results = pmap(list(1, 2), fun (n) { return list(id, P(n)); });
+			print results.get(0).get(0) == id; print results.get(1).get(1).twice();`, want: "true\n4\n"},
+		{name: "first error", source: `
+			pmap(list(1, 2, 3), fun (n) { print n; if (n == 2) return nil + 1; return n; });`,
+			want: "1\n2\n", err: "Operands must be two numbers or two strings."},
+		{name: "empty list", source: `print pmap(list(), fun (n) { return n; });`, want: "[]\n"},
+		{name: "not a list", source: `pmap(1, fun (n) { return n; });`, err: "Can only pmap over a list."},
+		{name: "not a function", source: `pmap(list(1), 2);`, err: "Expected a function to call on each element."},
+	})
+}
@@ -0,0 +1,378 @@
+package interpreter
+
+import (
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// Resolver is a static pass run between parsing and interpretation. It
+// tells the interpreter how many scopes out each local variable reference
+// resolves to, and reports the errors that can be found without running
+// the program. It also folds constant subexpressions in loops. References
+// to globals are left unresolved and looked up by name when they run,
+// since a global can be defined, or redefined, after code using it.
+type Resolver struct {
+	err         token.ErrorHandler
+	interpreter *Interpreter
+
+	// scopes is a stack of the block scopes enclosing the current node.
+	// Each maps a name to whether its initializer has finished resolving.
+	// Globals are not tracked.
+	scopes          []map[string]bool
+	currentFunction functionType
+	currentClass    classType
+	// loops counts the loops enclosing the current node, whose constant
+	// subexpressions are folded.
+	loops int
+	// loopBodies counts the loops enclosing the current node within its
+	// function, where break and continue may appear.
+	loopBodies int
+}
+
+type functionType int
+
+const (
+	functionNone functionType = iota
+	functionFunction
+	functionInitializer
+	functionMethod
+)
+
+type classType int
+
+const (
+	classNone classType = iota
+	classClass
+	classSubclass
+)
+
+// NewResolver returns a resolver that records its results in interpreter
+// and reports errors to err.
+func NewResolver(interpreter *Interpreter, err token.ErrorHandler) *Resolver {
+	return &Resolver{err: err, interpreter: interpreter}
+}
+
+// Resolve resolves a whole program. Errors are reported to the error
+// handler.
+func (r *Resolver) Resolve(statements []ast.Stmt) {
+	r.resolveStmts(statements)
+}
+
+// ResolveExpr resolves a standalone expression evaluated in the global
+// scope.
+func (r *Resolver) ResolveExpr(expr ast.Expr) {
+	r.resolveExpr(expr)
+}
+
+func (r *Resolver) resolveStmts(statements []ast.Stmt) {
+	for _, stmt := range statements {
+		r.resolveStmt(stmt)
+	}
+}
+
+// The resolver reports errors as it goes and never unwinds, so the error
+// results of Accept are always nil.
+
+func (r *Resolver) resolveStmt(stmt ast.Stmt) {
+	_ = stmt.Accept(r)
+}
+
+func (r *Resolver) resolveExpr(expr ast.Expr) {
+	_, _ = expr.Accept(r)
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, map[string]bool{})
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+// declare adds name to the innermost scope, marked as not yet ready for
+// use, so that a variable can't be read in its own initializer.
+func (r *Resolver) declare(name token.Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	if _, ok := scope[name.Lexeme]; ok {
+		r.err(name, "Already a variable with this name in this scope.")
+	}
+	scope[name.Lexeme] = false
+}
+
+func (r *Resolver) define(name token.Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	r.scopes[len(r.scopes)-1][name.Lexeme] = true
+}
+
+// resolveLocal records how many scopes out name is declared. Names that
+// are not found are assumed to be globals and left for the interpreter to
+// look up dynamically.
+func (r *Resolver) resolveLocal(expr ast.Expr, name string) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name]; ok {
+			r.interpreter.resolve(expr, len(r.scopes)-1-i)
+			return
+		}
+	}
+}
+
+func (r *Resolver) resolveFunction(function *ast.FunctionStmt, kind functionType) {
+	enclosing, enclosingLoops := r.currentFunction, r.loopBodies
+	r.currentFunction, r.loopBodies = kind, 0
+	defer func() { r.currentFunction, r.loopBodies = enclosing, enclosingLoops }()
+
+	r.beginScope()
+	for _, param := range function.Params {
+		r.declare(param)
+		r.define(param)
+	}
+	r.resolveStmts(function.Body)
+	r.endScope()
+}
+
+func (r *Resolver) VisitBlockStmt(stmt *ast.BlockStmt) error {
+	r.beginScope()
+	r.resolveStmts(stmt.Statements)
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitBreakStmt(stmt *ast.BreakStmt) error {
+	if r.loopBodies == 0 {
+		r.err(stmt.Keyword, "Can't use 'break' outside of a loop.")
+	}
+	return nil
+}
+
+func (r *Resolver) VisitClassStmt(stmt *ast.ClassStmt) error {
+	enclosing := r.currentClass
+	r.currentClass = classClass
+	defer func() { r.currentClass = enclosing }()
+
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+
+	if stmt.Superclass != nil {
+		if stmt.Superclass.Name.Lexeme == stmt.Name.Lexeme {
+			r.err(stmt.Superclass.Name, "A class can't inherit from itself.")
+		}
+		r.currentClass = classSubclass
+		r.resolveExpr(stmt.Superclass)
+
+		r.beginScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+		defer r.endScope()
+	}
+
+	r.beginScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+	for _, method := range stmt.Methods {
+		kind := functionMethod
+		if method.Name.Lexeme == "init" {
+			kind = functionInitializer
+		}
+		r.resolveFunction(method, kind)
+	}
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitContinueStmt(stmt *ast.ContinueStmt) error {
+	if r.loopBodies == 0 {
+		r.err(stmt.Keyword, "Can't use 'continue' outside of a loop.")
+	}
+	return nil
+}
+
+func (r *Resolver) VisitExpressionStmt(stmt *ast.ExpressionStmt) error {
+	r.resolveExpr(stmt.Expression)
+	stmt.Expression = r.fold(stmt.Expression)
+	return nil
+}
+
+func (r *Resolver) VisitFunctionStmt(stmt *ast.FunctionStmt) error {
+	// Define the name before resolving the body so the function can
+	// refer to itself recursively.
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+	r.resolveFunction(stmt, functionFunction)
+	return nil
+}
+
+func (r *Resolver) VisitIfStmt(stmt *ast.IfStmt) error {
+	r.resolveExpr(stmt.Condition)
+	stmt.Condition = r.fold(stmt.Condition)
+	r.resolveStmt(stmt.ThenBranch)
+	if stmt.ElseBranch != nil {
+		r.resolveStmt(stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitPrintStmt(stmt *ast.PrintStmt) error {
+	r.resolveExpr(stmt.Expression)
+	stmt.Expression = r.fold(stmt.Expression)
+	return nil
+}
+
+func (r *Resolver) VisitReturnStmt(stmt *ast.ReturnStmt) error {
+	if r.currentFunction == functionNone {
+		r.err(stmt.Keyword, "Can't return from top-level code.")
+	}
+	if stmt.Value != nil {
+		if r.currentFunction == functionInitializer {
+			r.err(stmt.Keyword, "Can't return a value from an initializer.")
+		}
+		r.resolveExpr(stmt.Value)
+		stmt.Value = r.fold(stmt.Value)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitVarStmt(stmt *ast.VarStmt) error {
+	r.declare(stmt.Name)
+	if stmt.Initializer != nil {
+		r.resolveExpr(stmt.Initializer)
+		stmt.Initializer = r.fold(stmt.Initializer)
+	}
+	r.define(stmt.Name)
+	return nil
+}
+
+func (r *Resolver) VisitWhileStmt(stmt *ast.WhileStmt) error {
+	r.loops++
+	r.loopBodies++
+	defer func() { r.loops--; r.loopBodies-- }()
+	r.resolveExpr(stmt.Condition)
+	stmt.Condition = r.fold(stmt.Condition)
+	r.resolveStmt(stmt.Body)
+	if stmt.Increment != nil {
+		r.resolveExpr(stmt.Increment)
+		stmt.Increment = r.fold(stmt.Increment)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitAssignExpr(expr *ast.AssignExpr) (any, error) {
+	r.resolveExpr(expr.Value)
+	expr.Value = r.fold(expr.Value)
+	r.resolveLocal(expr, expr.Name.Lexeme)
+	return nil, nil
+}
+
+func (r *Resolver) VisitBinaryExpr(expr *ast.BinaryExpr) (any, error) {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+	expr.Left, expr.Right = r.fold(expr.Left), r.fold(expr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitCallExpr(expr *ast.CallExpr) (any, error) {
+	r.resolveExpr(expr.Callee)
+	for n, argument := range expr.Arguments {
+		r.resolveExpr(argument)
+		expr.Arguments[n] = r.fold(argument)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitConditionalExpr(expr *ast.ConditionalExpr) (any, error) {
+	r.resolveExpr(expr.Condition)
+	r.resolveExpr(expr.ThenBranch)
+	r.resolveExpr(expr.ElseBranch)
+	expr.Condition, expr.ThenBranch, expr.ElseBranch = r.fold(expr.Condition), r.fold(expr.ThenBranch), r.fold(expr.ElseBranch)
+	return nil, nil
+}
+
+func (r *Resolver) VisitFunctionExpr(expr *ast.FunctionExpr) (any, error) {
+	r.resolveFunction(expr.Function, functionFunction)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGetExpr(expr *ast.GetExpr) (any, error) {
+	r.resolveExpr(expr.Object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGroupingExpr(expr *ast.GroupingExpr) (any, error) {
+	r.resolveExpr(expr.Expression)
+	expr.Expression = r.fold(expr.Expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIncrementExpr(expr *ast.IncrementExpr) (any, error) {
+	switch expr.Target.(type) {
+	case *ast.VariableExpr, *ast.GetExpr:
+	default:
+		r.err(expr.Operator, "Invalid '"+expr.Operator.Lexeme+"' target: expected a variable or field.")
+	}
+	r.resolveExpr(expr.Target)
+	return nil, nil
+}
+
+func (r *Resolver) VisitInterpolationExpr(expr *ast.InterpolationExpr) (any, error) {
+	for n, part := range expr.Parts {
+		r.resolveExpr(part)
+		expr.Parts[n] = r.fold(part)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteralExpr(expr *ast.LiteralExpr) (any, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogicalExpr(expr *ast.LogicalExpr) (any, error) {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+	expr.Left, expr.Right = r.fold(expr.Left), r.fold(expr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSetExpr(expr *ast.SetExpr) (any, error) {
+	r.resolveExpr(expr.Value)
+	expr.Value = r.fold(expr.Value)
+	r.resolveExpr(expr.Object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSuperExpr(expr *ast.SuperExpr) (any, error) {
+	switch r.currentClass {
+	case classNone:
+		r.err(expr.Keyword, "Can't use 'super' outside of a class.")
+	case classClass:
+		r.err(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+	}
+	r.resolveLocal(expr, "super")
+	return nil, nil
+}
+
+func (r *Resolver) VisitThisExpr(expr *ast.ThisExpr) (any, error) {
+	if r.currentClass == classNone {
+		r.err(expr.Keyword, "Can't use 'this' outside of a class.")
+		return nil, nil
+	}
+	r.resolveLocal(expr, "this")
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnaryExpr(expr *ast.UnaryExpr) (any, error) {
+	r.resolveExpr(expr.Right)
+	expr.Right = r.fold(expr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitVariableExpr(expr *ast.VariableExpr) (any, error) {
+	if len(r.scopes) > 0 {
+		if ready, ok := r.scopes[len(r.scopes)-1][expr.Name.Lexeme]; ok && !ready {
+			r.err(expr.Name, "Can't read local variable in its own initializer.")
+		}
+	}
+	r.resolveLocal(expr, expr.Name.Lexeme)
+	return nil, nil
+}
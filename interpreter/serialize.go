@@ -0,0 +1,230 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defineSerializeNatives defines serialize(value) and deserialize(text),
+// which turn values into text and back so that scripts can save state
+// between runs.
+//
+// The text is JSON. nil, booleans, finite numbers, strings and lists map
+// to their JSON counterparts; everything else is an object tagged with
+// what it holds:
+//
+//	{"$number": "NaN"}                      NaN and the infinities
+//	{"$bigint": "123456789012345678901"}    integers beyond float64
+//	{"$map": [[key, value], ...]}           maps, in insertion order
+//	{"$class": "Point", "fields": {...}}    instances
+//
+// Instances are restored by looking their class up among the globals by
+// name, without running init. Functions, classes and native objects such
+// as sockets can't be serialized, nor can values that contain themselves.
+// A value reachable along two paths is written, and read back, twice.
+func defineSerializeNatives(globals *Environment) {
+	globals.define("serialize", &nativeFunction{name: "serialize", arity: 1, fn: func(_ *Interpreter, args []any) (any, error) {
+		tree, err := encodeValue(args[0], map[any]bool{})
+		if err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		e := json.NewEncoder(&b)
+		e.SetEscapeHTML(false)
+		if err := e.Encode(tree); err != nil {
+			return nil, err
+		}
+		return strings.TrimSuffix(b.String(), "\n"), nil
+	}})
+	globals.define("deserialize", &nativeFunction{name: "deserialize", arity: 1, fn: func(i *Interpreter, args []any) (any, error) {
+		text, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("Can only deserialize strings.")
+		}
+		d := json.NewDecoder(strings.NewReader(text))
+		d.UseNumber()
+		var tree any
+		if err := d.Decode(&tree); err != nil {
+			return nil, fmt.Errorf("Malformed serialized value: %v.", err)
+		}
+		if d.More() {
+			return nil, errors.New("Malformed serialized value: unexpected text after the value.")
+		}
+		return i.decodeValue(tree)
+	}})
+}
+
+// encodeValue turns value into a tree that encoding/json can write.
+// active holds the lists, maps and instances being encoded, to catch
+// cycles.
+func encodeValue(value any, active map[any]bool) (any, error) {
+	switch v := value.(type) {
+	case nil, bool, string:
+		return v, nil
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return map[string]any{"$number": "NaN"}, nil
+		case math.IsInf(v, 1):
+			return map[string]any{"$number": "Infinity"}, nil
+		case math.IsInf(v, -1):
+			return map[string]any{"$number": "-Infinity"}, nil
+		}
+		return v, nil
+	case *big.Int:
+		return map[string]any{"$bigint": v.String()}, nil
+	case *LoxList, *LoxMap, *LoxInstance:
+		if active[v] {
+			return nil, errors.New("Can't serialize a value that contains itself.")
+		}
+		active[v] = true
+		defer delete(active, v)
+	default:
+		return nil, fmt.Errorf("Can't serialize %s.", Stringify(value))
+	}
+
+	switch v := value.(type) {
+	case *LoxList:
+		elements := make([]any, len(v.elements))
+		for n, element := range v.elements {
+			tree, err := encodeValue(element, active)
+			if err != nil {
+				return nil, err
+			}
+			elements[n] = tree
+		}
+		return elements, nil
+	case *LoxMap:
+		pairs := make([]any, 0, len(v.order))
+		for _, k := range v.order {
+			entry := v.entries[k]
+			key, err := encodeValue(entry.key, active)
+			if err != nil {
+				return nil, err
+			}
+			value, err := encodeValue(entry.value, active)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, []any{key, value})
+		}
+		return map[string]any{"$map": pairs}, nil
+	default:
+		in := v.(*LoxInstance)
+		fields := make(map[string]any, len(in.fields))
+		for name, field := range in.fields {
+			tree, err := encodeValue(field, active)
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = tree
+		}
+		return map[string]any{"$class": in.class.name, "fields": fields}, nil
+	}
+}
+
+// decodeValue turns a tree read by encoding/json back into a value.
+func (i *Interpreter) decodeValue(tree any) (any, error) {
+	switch t := tree.(type) {
+	case nil, bool, string:
+		return t, nil
+	case json.Number:
+		n, err := strconv.ParseFloat(string(t), 64)
+		if err != nil {
+			return nil, fmt.Errorf("Malformed serialized value: can't read the number %s.", t)
+		}
+		return n, nil
+	case []any:
+		elements := make([]any, len(t))
+		for n, element := range t {
+			value, err := i.decodeValue(element)
+			if err != nil {
+				return nil, err
+			}
+			elements[n] = value
+		}
+		return NewList(elements), nil
+	case map[string]any:
+		return i.decodeObject(t)
+	}
+	return nil, errors.New("Malformed serialized value.")
+}
+
+// decodeObject decodes one of the tagged objects encodeValue writes.
+func (i *Interpreter) decodeObject(object map[string]any) (any, error) {
+	malformed := errors.New("Malformed serialized value.")
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	switch strings.Join(keys, ",") {
+	case "$number":
+		switch object["$number"] {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+	case "$bigint":
+		if text, ok := object["$bigint"].(string); ok {
+			if n, ok := new(big.Int).SetString(text, 10); ok {
+				return normalizeBig(n), nil
+			}
+		}
+	case "$map":
+		pairs, ok := object["$map"].([]any)
+		if !ok {
+			return nil, malformed
+		}
+		m := NewMap()
+		for _, pair := range pairs {
+			kv, ok := pair.([]any)
+			if !ok || len(kv) != 2 {
+				return nil, malformed
+			}
+			key, err := i.decodeValue(kv[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := i.decodeValue(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			if err := m.Store(key, value); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case "$class,fields":
+		name, ok1 := object["$class"].(string)
+		fields, ok2 := object["fields"].(map[string]any)
+		if !ok1 || !ok2 {
+			return nil, malformed
+		}
+		class, ok := i.globals.values[name].(*LoxClass)
+		if !ok {
+			return nil, fmt.Errorf("Can't deserialize an instance of '%s': no global class has that name.", name)
+		}
+		in := &LoxInstance{class: class, fields: make(map[string]any, len(fields))}
+		for field, tree := range fields {
+			value, err := i.decodeValue(tree)
+			if err != nil {
+				return nil, err
+			}
+			in.fields[field] = value
+		}
+		return in, nil
+	}
+	return nil, malformed
+}
@@ -0,0 +1,47 @@
+package interpreter
+
+import "testing"
+
+func TestSerialize(t *testing.T) {
+	runPrograms(t, []programTest{
+		{name: "plain values", source: `
+			print serialize(nil); print serialize(true); print serialize(1.5); print serialize("a \"q\" <b>");
+			print serialize(list(1, list("x"), nil));`,
+			want: "null\ntrue\n1.5\n\"a \\\"q\\\" <b>\"\n[1,[\"x\"],null]\n"},
+		{name: "tagged values", source: `
+			print serialize(0 / 0); print serialize(-1 / 0);
+			var m = map(); m.set("b", 1); m.set(2, "a");
+			print serialize(m);`,
+			want: "{\"$number\":\"NaN\"}\n{\"$number\":\"-Infinity\"}\n{\"$map\":[[\"b\",1],[2,\"a\"]]}\n"},
+		{name: "instance", source: `
+			class Point { init(x) { this.x = x; } }
+			print serialize(Point(3));`, want: "{\"$class\":\"Point\",\"fields\":{\"x\":3}}\n"},
+		{name: "round trip", source: `
+			var m = map(); m.set("k", list(1, "two", nil, true)); m.set(3, 1 / 0);
+			var text = serialize(list(m, "s", 0.25));
+			var back = deserialize(text);
+			print back; print serialize(back) == text; print back.get(0).get(3);`,
+			want: "[{k: [1, two, nil, true], 3: Infinity}, s, 0.25]\ntrue\nInfinity\n"},
+		{name: "instance round trip", source: `
+			var inits = 0;
+			class Point { init(x) { inits++; this.x = x; } double() { return this.x * 2; } }
+			var p = deserialize(serialize(Point(4)));
+			print p.double(); print inits; print p;`, want: "8\n1\nPoint instance\n"},
+		{name: "bigint round trip", source: `
+			var text = "{\"$bigint\":\"123456789012345678901\"}";
+			var n = deserialize(text);
+			print n; print serialize(n) == text;`, want: "123456789012345678901\ntrue\n"},
+		{name: "shared values are written twice", source: `
+			var a = list(1); var text = serialize(list(a, a));
+			var back = deserialize(text);
+			print text; back.get(0).push(2); print back;`, want: "[[1],[1]]\n[[1, 2], [1]]\n"},
+		{name: "list cycle", source: `var l = list(); l.push(l); serialize(l);`, err: "Can't serialize a value that contains itself."},
+		{name: "map cycle", source: `var m = map(); m.set("l", list(m)); serialize(m);`, err: "Can't serialize a value that contains itself."},
+		{name: "instance cycle", source: `class A {} var a = A(); a.self = a; serialize(a);`, err: "Can't serialize a value that contains itself."},
+		{name: "function", source: `fun f() {} serialize(list(f));`, err: "Can't serialize <fn f>."},
+		{name: "unknown class", source: `deserialize("{\"$class\":\"Gone\",\"fields\":{}}");`, err: "Can't deserialize an instance of 'Gone': no global class has that name."},
+		{name: "unknown tag", source: `deserialize("{\"$other\":1}");`, err: "Malformed serialized value."},
+		{name: "trailing text", source: `deserialize("1 2");`, err: "Malformed serialized value: unexpected text after the value."},
+		{name: "not a string", source: `deserialize(1);`, err: "Can only deserialize strings."},
+	})
+}
@@ -0,0 +1,119 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// signals holds the handlers installed by onSignal and the signals that
+// have arrived for them but not yet been handled.
+type signals struct {
+	handlers map[os.Signal]LoxCallable
+	pending  chan os.Signal
+	// handling is set while a handler runs, so that a second signal waits
+	// for it to finish rather than interrupting it.
+	handling bool
+}
+
+// signalNames maps the names onSignal takes to the signals they stand for.
+var signalNames = map[string]os.Signal{
+	"INT":  os.Interrupt,
+	"TERM": syscall.SIGTERM,
+	"HUP":  syscall.SIGHUP,
+}
+
+// EnableSignals defines onSignal, which lets a long-running script, such
+// as a server or a watcher, clean up when it is told to stop:
+//
+//	onSignal(name, fn)  call fn() when the process receives the signal
+//	                    "INT" (Ctrl+C), "TERM" or "HUP"; with fn nil,
+//	                    stop trapping it
+//
+// A signal is handled between statements, or while runLoop waits, never
+// in the middle of a statement. When the handler returns, the script
+// carries on where it was interrupted; the handler can call exit to end
+// it instead. Signals that are not trapped keep their usual effect, which
+// for all three is to end the process.
+func (i *Interpreter) EnableSignals() {
+	i.DefineNative("onSignal", 2, func(i *Interpreter, args []any) (any, error) {
+		name, _ := args[0].(string)
+		sig, ok := signalNames[name]
+		if !ok {
+			return nil, errors.New(`Signal must be "INT", "TERM" or "HUP".`)
+		}
+		if args[1] == nil {
+			i.signals.untrap(sig)
+			return nil, nil
+		}
+		handler, ok := args[1].(LoxCallable)
+		if !ok {
+			return nil, errors.New("Signal handler must be a function or nil.")
+		}
+		if arity := handler.Arity(); arity > 0 {
+			return nil, fmt.Errorf("Signal handler must take no arguments, but takes %d.", arity)
+		}
+		i.signals.trap(sig, handler)
+		return nil, nil
+	})
+}
+
+func (s *signals) trap(sig os.Signal, handler LoxCallable) {
+	if s.pending == nil {
+		s.handlers = map[os.Signal]LoxCallable{}
+		s.pending = make(chan os.Signal, len(signalNames))
+	}
+	s.handlers[sig] = handler
+	signal.Notify(s.pending, sig)
+}
+
+func (s *signals) untrap(sig os.Signal) {
+	if _, ok := s.handlers[sig]; !ok {
+		return
+	}
+	delete(s.handlers, sig)
+	// Stop and Notify again rather than Reset, which would also undo
+	// whatever the host asked for.
+	signal.Stop(s.pending)
+	for sig := range s.handlers {
+		signal.Notify(s.pending, sig)
+	}
+}
+
+// reset stops trapping every signal.
+func (s *signals) reset() {
+	if s.pending != nil {
+		signal.Stop(s.pending)
+	}
+	*s = signals{}
+}
+
+// handleSignals runs the handlers of the signals that have arrived since
+// it was last called, unless a handler is already running.
+func (i *Interpreter) handleSignals() error {
+	for !i.signals.handling {
+		select {
+		case sig := <-i.signals.pending:
+			if err := i.handleSignal(sig); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) handleSignal(sig os.Signal) error {
+	// The signal may have arrived just before its handler was removed.
+	handler, ok := i.signals.handlers[sig]
+	if !ok {
+		return nil
+	}
+	i.signals.handling = true
+	defer func() { i.signals.handling = false }()
+	_, err := i.Call(handler, nil)
+	return err
+}
@@ -0,0 +1,44 @@
+package interpreter
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestOnSignal(t *testing.T) {
+	signals := func(i *Interpreter) {
+		i.EnableSignals()
+		// Stop trapping once the test is over, so that a later signal
+		// isn't lost on a channel nobody reads.
+		t.Cleanup(i.signals.reset)
+	}
+	// raise sends the process a SIGHUP, which arrives while the script
+	// counts to 1000.
+	raise := func(i *Interpreter) {
+		signals(i)
+		i.DefineNative("raise", 0, func(*Interpreter, []any) (any, error) {
+			p, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return nil, err
+			}
+			return nil, p.Signal(syscall.SIGHUP)
+		})
+	}
+	runPrograms(t, []programTest{
+		{name: "unknown signal", source: `onSignal("KILL", fun () {});`, err: `Signal must be "INT", "TERM" or "HUP".`, setup: signals},
+		{name: "not a function", source: `onSignal("HUP", 1);`, err: "Signal handler must be a function or nil.", setup: signals},
+		{name: "handler with parameters", source: `onSignal("HUP", fun (sig) {});`, err: "Signal handler must take no arguments, but takes 1.", setup: signals},
+		{name: "untrap what was never trapped", source: `onSignal("HUP", nil); print "ok";`, want: "ok\n", setup: signals},
+		{name: "handler runs", source: `
+			var got = false;
+			onSignal("HUP", fun () { got = true; });
+			raise();
+			for (var i = 0; !got and i < 1000000; i++) {}
+			print got;`, want: "true\n", setup: raise},
+		{name: "handler error", source: `
+			onSignal("HUP", fun () { nil + 1; });
+			raise();
+			for (var i = 0; i < 1000000; i++) {}`, err: "Operands must be two numbers or two strings.", setup: raise},
+	})
+}
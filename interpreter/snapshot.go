@@ -0,0 +1,42 @@
+package interpreter
+
+import (
+	"maps"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+)
+
+// Snapshot is the global state of an interpreter at one moment, such as
+// just after a prelude ran, from which Reset can start interpreters over.
+// It shares nothing with the interpreter it was taken from, and Reset only
+// reads it, so several goroutines may reset from the same Snapshot at
+// once.
+type Snapshot struct {
+	globals *Environment
+	locals  map[ast.Expr]int
+}
+
+// Snapshot copies the global state of i: its global variables and every
+// function, class, list, map and instance they reach, along with what the
+// resolver has recorded about the code run so far. Natives and native
+// objects are shared, as pmap shares them.
+func (i *Interpreter) Snapshot() *Snapshot {
+	return &Snapshot{globals: newHeapCopy().environment(i.globals), locals: maps.Clone(i.locals)}
+}
+
+// Reset puts i back in the state captured by snapshot, which may have been
+// taken from another interpreter, discarding whatever has been defined or
+// changed since: globals, objects, pending timers and event handlers, and
+// trapped signals. The mutation log, if any, is emptied. The settings held
+// in exported fields, and where print writes, are kept.
+func (i *Interpreter) Reset(snapshot *Snapshot) {
+	i.globals = newHeapCopy().environment(snapshot.globals)
+	i.environment = i.globals
+	i.locals = maps.Clone(snapshot.locals)
+	i.depth = 0
+	i.events = events{}
+	i.signals.reset()
+	if i.Mutations != nil {
+		i.Mutations = NewMutationLog(len(i.Mutations.entries))
+	}
+}
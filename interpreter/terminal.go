@@ -0,0 +1,113 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"unicode/utf8"
+)
+
+// EnableTerminal defines the terminal natives, for small games and text
+// user interfaces. Output goes wherever print writes and uses ANSI escape
+// sequences; keys are read from standard input.
+//
+//	termClear()        clear the screen and move the cursor to its top left
+//	termMoveTo(x, y)   move the cursor to column x, row y, counting from 0
+//	termColor(fg)      set the text color, by name ("red", "bright blue",
+//	                   "reset", ...) or as a 256-color palette index
+//	readKey()          wait for a key press without echoing it, returning
+//	                   the character or a name such as "up", "enter" or
+//	                   "escape"; nil at the end of input
+func (i *Interpreter) EnableTerminal() {
+	i.DefineNative("termClear", 0, func(i *Interpreter, _ []any) (any, error) {
+		_, err := io.WriteString(i.stdout, "\x1b[2J\x1b[H")
+		return nil, err
+	})
+	i.DefineNative("termMoveTo", 2, func(i *Interpreter, args []any) (any, error) {
+		x, ok1 := toNumber(args[0])
+		y, ok2 := toNumber(args[1])
+		if !ok1 || !ok2 || x < 0 || y < 0 || x != math.Trunc(x) || y != math.Trunc(y) {
+			return nil, errors.New("Cursor position must be non-negative integers.")
+		}
+		_, err := fmt.Fprintf(i.stdout, "\x1b[%d;%dH", int(y)+1, int(x)+1)
+		return nil, err
+	})
+	i.DefineNative("termColor", 1, func(i *Interpreter, args []any) (any, error) {
+		code, err := colorCode(args[0])
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(i.stdout, "\x1b["+code+"m")
+		return nil, err
+	})
+	i.DefineNative("readKey", 0, func(_ *Interpreter, _ []any) (any, error) {
+		return readKey(os.Stdin)
+	})
+}
+
+var colorCodes = map[string]string{
+	"reset": "0",
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+	"bright black": "90", "bright red": "91", "bright green": "92", "bright yellow": "93",
+	"bright blue": "94", "bright magenta": "95", "bright cyan": "96", "bright white": "97",
+}
+
+func colorCode(color any) (string, error) {
+	if name, ok := color.(string); ok {
+		if code, ok := colorCodes[name]; ok {
+			return code, nil
+		}
+		return "", fmt.Errorf("Unknown color '%s'.", name)
+	}
+	if n, ok := toNumber(color); ok && n >= 0 && n <= 255 && n == math.Trunc(n) {
+		return fmt.Sprintf("38;5;%d", int(n)), nil
+	}
+	return "", errors.New("Color must be a name or a number from 0 to 255.")
+}
+
+// keyNames names the keys whose input isn't a printable character.
+var keyNames = map[string]string{
+	"\r": "enter", "\n": "enter", "\t": "tab", "\x7f": "backspace", "\b": "backspace",
+	"\x1b": "escape", " ": "space",
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	"\x1b[H": "home", "\x1b[F": "end", "\x1b[3~": "delete",
+	"\x1b[5~": "page up", "\x1b[6~": "page down",
+}
+
+// readKey reads one key press from f. When f is a terminal it is switched
+// out of line-buffered mode for the read, so the key arrives without
+// waiting for enter and isn't echoed. A key that sends an escape sequence
+// arrives in one read, which is how it is told apart from escape itself.
+// Other input is read one character at a time.
+func readKey(f *os.File) (any, error) {
+	buf := make([]byte, 16)
+	var n int
+	var err error
+	if restore, cbreakErr := makeCbreak(f); cbreakErr == nil {
+		n, err = f.Read(buf)
+		restore()
+	} else {
+		for n < utf8.UTFMax && !utf8.FullRune(buf[:n]) {
+			var m int
+			if m, err = f.Read(buf[n : n+1]); m == 0 {
+				break
+			}
+			n++
+		}
+	}
+	if n == 0 {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Can't read a key: %v.", err)
+	}
+	key := string(buf[:n])
+	if name, ok := keyNames[key]; ok {
+		return name, nil
+	}
+	return key, nil
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package interpreter
+
+import (
+	"errors"
+	"os"
+)
+
+// makeCbreak is not supported here, so readKey waits for a whole line.
+func makeCbreak(*os.File) (restore func(), err error) {
+	return nil, errors.New("unsupported platform")
+}
@@ -0,0 +1,45 @@
+package interpreter
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTerminal(t *testing.T) {
+	terminal := func(i *Interpreter) { i.EnableTerminal() }
+	runPrograms(t, []programTest{
+		{name: "clear", source: `termClear();`, want: "\x1b[2J\x1b[H", setup: terminal},
+		{name: "move", source: `termMoveTo(0, 0); termMoveTo(9, 4);`, want: "\x1b[1;1H\x1b[5;10H", setup: terminal},
+		{name: "named colors", source: `termColor("red"); termColor("bright cyan"); termColor("reset");`, want: "\x1b[31m\x1b[96m\x1b[0m", setup: terminal},
+		{name: "palette color", source: `termColor(208);`, want: "\x1b[38;5;208m", setup: terminal},
+		{name: "negative position", source: `termMoveTo(-1, 0);`, err: "Cursor position must be non-negative integers.", setup: terminal},
+		{name: "fractional position", source: `termMoveTo(1, 1.5);`, err: "Cursor position must be non-negative integers.", setup: terminal},
+		{name: "unknown color", source: `termColor("mauve");`, err: "Unknown color 'mauve'.", setup: terminal},
+		{name: "palette out of range", source: `termColor(256);`, err: "Color must be a name or a number from 0 to 255.", setup: terminal},
+		{name: "color of the wrong type", source: `termColor(nil);`, err: "Color must be a name or a number from 0 to 255.", setup: terminal},
+		{name: "disabled by default", source: `termClear();`, err: "Undefined variable 'termClear'."},
+	})
+}
+
+// TestReadKey reads keys from a pipe, which, not being a terminal, is read
+// a character at a time.
+func TestReadKey(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	go func() {
+		w.WriteString("aé\r \x7f")
+		w.Close()
+	}()
+	for _, want := range []any{"a", "é", "enter", "space", "backspace", nil} {
+		got, err := readKey(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package interpreter
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// makeCbreak turns off line buffering and echo on the terminal f, leaving
+// signals such as Ctrl-C working, and returns a function restoring the
+// previous settings. It fails if f is not a terminal.
+func makeCbreak(f *os.File) (restore func(), err error) {
+	var saved syscall.Termios
+	if err := termios(f, ioctlGetTermios, &saved); err != nil {
+		return nil, err
+	}
+	cbreak := saved
+	cbreak.Lflag &^= syscall.ICANON | syscall.ECHO
+	cbreak.Cc[syscall.VMIN] = 1
+	cbreak.Cc[syscall.VTIME] = 0
+	if err := termios(f, ioctlSetTermios, &cbreak); err != nil {
+		return nil, err
+	}
+	return func() { _ = termios(f, ioctlSetTermios, &saved) }, nil
+}
+
+func termios(f *os.File, request uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), request, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
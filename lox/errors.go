@@ -0,0 +1,48 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// Error is a compile error: a lexical, syntax or scoping error found
+// before the program runs.
+type Error struct {
+	File    string // set only when a directive named the file
+	Line    int
+	Column  int
+	Where   string // e.g. " at 'x'" or " at end"; empty for lexical errors
+	Message string
+}
+
+// Error formats e the way the command-line interpreter reports it.
+func (e *Error) Error() string {
+	location := fmt.Sprintf("line %d, col %d", e.Line, e.Column)
+	if e.File != "" {
+		location += " in " + e.File
+	}
+	return fmt.Sprintf("[%s] Error%s: %s", location, e.Where, e.Message)
+}
+
+// ErrorList is every compile error found in a piece of source, in the
+// order they were reported.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// addTokenError is a token.ErrorHandler that collects errors into l.
+func (l *ErrorList) addTokenError(tok token.Token, message string) {
+	where := " at '" + tok.Lexeme + "'"
+	if tok.Type == token.EOF {
+		where = " at end"
+	}
+	*l = append(*l, &Error{File: tok.File, Line: tok.Line, Column: tok.Column, Where: where, Message: message})
+}
@@ -0,0 +1,348 @@
+package lox
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+// goObject exposes a Go struct to Lox. Its exported fields read and
+// assign as properties, and its exported methods are callable. Property
+// names match the Go names, or the Go names with the first letter
+// lowercased, so a field Name is reachable as either obj.Name or obj.name.
+type goObject struct {
+	// ptr points to the struct, so that fields are assignable and
+	// pointer-receiver methods are in the method set.
+	ptr reflect.Value
+	// owner is the interpreter the object has been handed to, which is
+	// nil until ValueOf's result is given to one.
+	owner *Interpreter
+}
+
+// wrapStruct exposes the struct rv, or the struct it points to. A struct
+// passed by value is copied, so assignments from Lox are not seen by the
+// caller.
+func wrapStruct(rv reflect.Value, owner *Interpreter) *goObject {
+	if rv.Kind() != reflect.Pointer {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr
+	}
+	return &goObject{ptr: rv, owner: owner}
+}
+
+func isStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct
+}
+
+// goName maps a Lox property name to the exported Go name it refers to.
+func goName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+func (o *goObject) field(name string) (reflect.Value, bool) {
+	for _, candidate := range []string{name, goName(name)} {
+		if f, ok := o.ptr.Elem().Type().FieldByName(candidate); ok && f.IsExported() {
+			return o.ptr.Elem().FieldByIndex(f.Index), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (o *goObject) Get(name string) (any, error) {
+	if field, ok := o.field(name); ok {
+		value, err := toLox(field, o.owner)
+		if err != nil {
+			return nil, fmt.Errorf("Can't read field '%s': %v", name, err)
+		}
+		return value, nil
+	}
+	for _, candidate := range []string{name, goName(name)} {
+		if method := o.ptr.MethodByName(candidate); method.IsValid() {
+			return &goMethod{name: candidate, fn: method, owner: o.owner}, nil
+		}
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (o *goObject) Set(name string, value any) error {
+	field, ok := o.field(name)
+	if !ok {
+		return fmt.Errorf("Undefined field '%s'.", name)
+	}
+	converted, err := fromLox(value, field.Type(), o.owner, nil)
+	if err != nil {
+		return fmt.Errorf("Can't assign field '%s': %v", name, err)
+	}
+	field.Set(converted)
+	return nil
+}
+
+func (o *goObject) String() string {
+	return o.ptr.Elem().Type().Name() + " instance"
+}
+
+// goMethod is a Go method bound to its receiver.
+type goMethod struct {
+	name  string
+	fn    reflect.Value
+	owner *Interpreter
+}
+
+func (m *goMethod) Arity() int {
+	if m.fn.Type().IsVariadic() {
+		return -1
+	}
+	return m.fn.Type().NumIn()
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func (m *goMethod) Call(_ *interpreter.Interpreter, arguments []any) (any, error) {
+	t := m.fn.Type()
+	if t.IsVariadic() && len(arguments) < t.NumIn()-1 {
+		return nil, fmt.Errorf("Expected at least %d arguments but got %d.", t.NumIn()-1, len(arguments))
+	}
+	call := &goCall{}
+	in := make([]reflect.Value, len(arguments))
+	for i, argument := range arguments {
+		paramType := t.In(min(i, t.NumIn()-1))
+		if t.IsVariadic() && i >= t.NumIn()-1 {
+			paramType = paramType.Elem()
+		}
+		value, err := fromLox(argument, paramType, m.owner, call)
+		if err != nil {
+			return nil, fmt.Errorf("Argument %d to %s: %v", i+1, m.name, err)
+		}
+		in[i] = value
+	}
+
+	var out []reflect.Value
+	if err := m.owner.callOut(call, func() { out = m.fn.Call(in) }); err != nil {
+		return nil, err
+	}
+	if n := len(out); n > 0 && t.Out(n-1) == errorType {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		out = out[:n-1]
+	}
+	switch len(out) {
+	case 0:
+		return nil, nil
+	case 1:
+		return toLox(out[0], m.owner)
+	}
+	return nil, fmt.Errorf("Method %s returns %d values; Lox can only receive one.", m.name, len(out))
+}
+
+func (m *goMethod) String() string {
+	return "<native fn>"
+}
+
+// toLox converts a Go value read by reflection to its Lox representation.
+// Structs become objects belonging to owner.
+func toLox(rv reflect.Value, owner *Interpreter) (any, error) {
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if v, ok := rv.Interface().(Value); ok {
+			return v.v, nil
+		}
+		return toLox(rv.Elem(), owner)
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	if v, ok := rv.Interface().(Value); ok {
+		return v.v, nil
+	}
+	if isStruct(rv.Type()) {
+		return wrapStruct(rv, owner), nil
+	}
+	return nil, fmt.Errorf("Go type %s has no Lox equivalent.", rv.Type())
+}
+
+// fromLox converts a Lox value, owned by owner, to the Go type t. call is
+// the call to a Go method that value is an argument of, if it is one.
+func fromLox(value any, t reflect.Type, owner *Interpreter, call *goCall) (reflect.Value, error) {
+	if t == reflect.TypeOf(Value{}) {
+		return reflect.ValueOf(Value{v: value, owner: owner}), nil
+	}
+	if o, ok := value.(*goObject); ok {
+		for _, rv := range []reflect.Value{o.ptr, o.ptr.Elem()} {
+			if rv.Type().AssignableTo(t) {
+				return rv, nil
+			}
+		}
+	}
+	if n, ok := value.(*big.Int); ok {
+		f, _ := new(big.Float).SetInt(n).Float64()
+		if t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 || !n.IsInt64() {
+			value = f
+		} else {
+			value = float64(n.Int64())
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if value == nil {
+			return reflect.Zero(t), nil
+		}
+		if rv := reflect.ValueOf(value); rv.Type().AssignableTo(t) {
+			return rv, nil
+		}
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			return reflect.ValueOf(b).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := value.(float64); ok {
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f, ok := value.(float64); ok {
+			rv := reflect.New(t).Elem()
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || rv.OverflowInt(int64(f)) {
+				return reflect.Value{}, fmt.Errorf("%s does not fit in %s.", interpreter.Stringify(f), t)
+			}
+			rv.SetInt(int64(f))
+			return rv, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if f, ok := value.(float64); ok {
+			rv := reflect.New(t).Elem()
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || rv.OverflowUint(uint64(f)) {
+				return reflect.Value{}, fmt.Errorf("%s does not fit in %s.", interpreter.Stringify(f), t)
+			}
+			rv.SetUint(uint64(f))
+			return rv, nil
+		}
+	case reflect.Func:
+		if callable, ok := value.(interpreter.LoxCallable); ok && owner != nil {
+			return owner.makeFunc(callable, t, call), nil
+		}
+		if value == nil {
+			return reflect.Zero(t), nil
+		}
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if value == nil {
+			return reflect.Zero(t), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("expected %s but got %s.", t, Value{v: value}.Kind())
+}
+
+// makeFunc returns a Go function of type t that calls callable, so Lox
+// functions can be passed to Go methods expecting a callback, such as a
+// comparison function. Results are converted to t's result types.
+//
+// If the call fails and t has no error result to report it through, what
+// happens depends on where it was made. Made by call, the Go method that
+// received the function, on the goroutine running it, the method is
+// abandoned with a runtime error. Otherwise, as when the function was
+// stored and is called later or from another goroutine, there is no
+// script to fail: the error goes to the handler set by WithCallbackErrors,
+// and the function returns zero values.
+func (in *Interpreter) makeFunc(callable interpreter.LoxCallable, t reflect.Type, call *goCall) reflect.Value {
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		out := make([]reflect.Value, t.NumOut())
+		for i := range out {
+			out[i] = reflect.Zero(t.Out(i))
+		}
+		fail := func(err error) []reflect.Value {
+			if n := t.NumOut(); n > 0 && t.Out(n-1) == errorType {
+				out[n-1] = reflect.ValueOf(&err).Elem()
+				return out
+			}
+			if call != nil && call.onGoroutine() {
+				panic(callbackError{err})
+			}
+			in.config.callbackErrors(err)
+			return out
+		}
+
+		arguments := make([]any, len(args))
+		for i, arg := range args {
+			value, err := toLox(arg, in)
+			if err != nil {
+				return fail(err)
+			}
+			arguments[i] = value
+		}
+		var result Value
+		var err error
+		in.callBack(func() { result, err = in.call(callable, arguments) })
+		if err != nil {
+			return fail(err)
+		}
+		if t.NumOut() > 0 && t.Out(0) != errorType {
+			converted, err := fromLox(result.v, t.Out(0), in, nil)
+			if err != nil {
+				return fail(fmt.Errorf("Callback result: %v", err))
+			}
+			out[0] = converted
+		}
+		return out
+	})
+}
+
+// callbackError carries the error of a Lox callback out of Go code that
+// gave it no way to return one.
+type callbackError struct {
+	err error
+}
+
+// goCall is a call from a script to a Go method. Lox functions passed to
+// the method can abandon it only while it is running, and only from the
+// goroutine running it, since only there is callOut waiting to recover.
+type goCall struct {
+	// goroutine is the ID of the goroutine running the method, or 0 when it
+	// is not running.
+	goroutine atomic.Uint64
+}
+
+// onGoroutine reports whether the method is running on the calling
+// goroutine.
+func (c *goCall) onGoroutine() bool {
+	id := c.goroutine.Load()
+	return id != 0 && id == goroutineID()
+}
+
+// goroutineID returns the ID of the calling goroutine, which the runtime
+// only reveals in stack traces.
+func goroutineID() uint64 {
+	var buf [64]byte
+	trace := buf[:runtime.Stack(buf[:], false)]
+	trace = bytes.TrimPrefix(trace, []byte("goroutine "))
+	id, _ := strconv.ParseUint(string(trace[:bytes.IndexByte(trace, ' ')]), 10, 64)
+	return id
+}
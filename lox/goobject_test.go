@@ -0,0 +1,81 @@
+package lox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+// callbacks takes Lox functions as Go funcs with no error result.
+type callbacks struct {
+	stored func(int) int
+}
+
+func (c *callbacks) Apply(f func(int) int) int { return f(1) + 1 }
+func (c *callbacks) Store(f func(int) int)     { c.stored = f }
+
+// ApplyElsewhere calls f on another goroutine while it is still running.
+func (c *callbacks) ApplyElsewhere(f func(int) int) int {
+	result := make(chan int)
+	go func() { result <- f(1) }()
+	return <-result + 1
+}
+
+func TestCallbackErrorDuringCall(t *testing.T) {
+	in := New(WithCallbackErrors(func(err error) {
+		t.Errorf("handler called with %v, want the error to fail the script", err)
+	}))
+	in.Define("go", ValueOf(&callbacks{}))
+	err := in.Run(`print go.apply(fun (n) { return n + nil; });`)
+	var rt *interpreter.RuntimeError
+	if !errors.As(err, &rt) || !strings.Contains(rt.Message, "Operands") {
+		t.Errorf("got %v, want the callback's runtime error", err)
+	}
+}
+
+func TestCallbackErrorAfterCall(t *testing.T) {
+	handled := make(chan error, 1)
+	in := New(WithCallbackErrors(func(err error) { handled <- err }))
+	c := &callbacks{}
+	in.Define("go", ValueOf(c))
+	if err := in.Run(`go.store(fun (n) { return n + nil; });`); err != nil {
+		t.Fatal(err)
+	}
+
+	results := make(chan int)
+	go func() { results <- c.stored(1) }()
+	if got := <-results; got != 0 {
+		t.Errorf("got %d, want the zero value", got)
+	}
+	select {
+	case err := <-handled:
+		if !strings.Contains(err.Error(), "Operands") {
+			t.Errorf("handler got %v, want the callback's runtime error", err)
+		}
+	default:
+		t.Error("the handler was not called")
+	}
+}
+
+func TestCallbackErrorOnAnotherGoroutine(t *testing.T) {
+	handled := make(chan error, 1)
+	var out strings.Builder
+	in := New(WithStdout(&out), WithCallbackErrors(func(err error) { handled <- err }))
+	in.Define("go", ValueOf(&callbacks{}))
+	if err := in.Run(`print go.applyElsewhere(fun (n) { return n + nil; });`); err != nil {
+		t.Fatalf("got %v, want the method to carry on", err)
+	}
+	if out.String() != "1\n" {
+		t.Errorf("got %q, want the method to see the zero value", out.String())
+	}
+	select {
+	case err := <-handled:
+		if !strings.Contains(err.Error(), "Operands") {
+			t.Errorf("handler got %v, want the callback's runtime error", err)
+		}
+	default:
+		t.Error("the handler was not called")
+	}
+}
@@ -0,0 +1,196 @@
+// Package lox embeds the Lox interpreter in Go programs.
+//
+//	in := lox.New(lox.WithStdout(&buf), lox.WithMaxDepth(200))
+//	if err := in.Run(`print "hello";`); err != nil {
+//		log.Fatal(err)
+//	}
+package lox
+
+import (
+	"os"
+	"sync"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/interpreter"
+	"github.com/kriyanshii/interpreter-go/parser"
+	"github.com/kriyanshii/interpreter-go/scanner"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// Interpreter runs Lox source. Globals defined by one call to Run remain
+// visible to later calls.
+//
+// An Interpreter may be used from several goroutines. Its methods, and
+// callbacks obtained from Value.AsCallable, run one at a time; the lock is
+// released only while a script is calling into Go, through a native or a
+// method of a Go object, so that Go code can call back into Lox.
+type Interpreter struct {
+	config config
+	interp *interpreter.Interpreter
+	mu     sync.Mutex
+}
+
+// New returns an interpreter configured by opts.
+func New(opts ...Option) *Interpreter {
+	c := config{stdout: os.Stdout, maxDepth: interpreter.DefaultMaxDepth, callbackErrors: logCallbackError}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	interp := interpreter.New(c.stdout)
+	interp.BigInt = c.dialect.BigInt
+	interp.Strict = c.strict
+	interp.MaxDepth = c.maxDepth
+	if c.mutationLog > 0 {
+		interp.Mutations = interpreter.NewMutationLog(c.mutationLog)
+	}
+	if c.auditLog != nil {
+		interp.Audit = interpreter.NewAuditLog(c.auditLog)
+	}
+	if c.network {
+		interp.EnableNetwork()
+	}
+	if c.fileSystem {
+		interp.EnableFileSystem()
+	}
+	if c.graphics {
+		interp.EnableGraphics()
+	}
+	if c.terminal {
+		interp.EnableTerminal()
+	}
+	if c.signals {
+		interp.EnableSignals()
+	}
+	return &Interpreter{config: c, interp: interp}
+}
+
+// Run scans, parses and executes source. Compile errors are returned
+// together as an ErrorList, without running anything; a runtime error is
+// returned as an *interpreter.RuntimeError. A script that calls exit stops
+// there, and Run returns an *interpreter.ExitError carrying its status.
+func (in *Interpreter) Run(source string) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	statements, err := in.compile(source)
+	if err != nil {
+		return err
+	}
+	return in.interp.Interpret(statements)
+}
+
+// MutationLog returns the recent variable mutations, or nil unless the
+// interpreter was created WithMutationLog.
+func (in *Interpreter) MutationLog() *interpreter.MutationLog {
+	return in.interp.Mutations
+}
+
+// call calls a Lox callable from Go with Lox arguments. The caller must
+// hold the lock.
+func (in *Interpreter) call(callable interpreter.LoxCallable, arguments []any) (Value, error) {
+	result, err := in.interp.Call(callable, arguments)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{v: result, owner: in}, nil
+}
+
+// callOut runs fn, Go code called from a script, with the lock released
+// so that fn may call back into the interpreter. When fn is the Go method
+// of call, callOut returns the error of a callback that failed inside it
+// with nowhere else to report it.
+func (in *Interpreter) callOut(call *goCall, fn func()) (err error) {
+	if in == nil {
+		fn()
+		return nil
+	}
+	if call != nil {
+		call.goroutine.Store(goroutineID())
+		defer call.goroutine.Store(0)
+	}
+	in.mu.Unlock()
+	defer in.mu.Lock()
+	defer func() {
+		if r := recover(); r != nil {
+			failed, ok := r.(callbackError)
+			if !ok {
+				panic(r)
+			}
+			err = failed.err
+		}
+	}()
+	fn()
+	return nil
+}
+
+// callBack runs fn, a call from Go into Lox, once the interpreter is free.
+func (in *Interpreter) callBack(fn func()) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	fn()
+}
+
+// adopt makes Go objects created by ValueOf belong to in, so that Lox
+// functions passed to their methods can be called back.
+func (in *Interpreter) adopt(value any) any {
+	if o, ok := value.(*goObject); ok && o.owner == nil {
+		o.owner = in
+	}
+	return value
+}
+
+// Eval evaluates a single expression, such as "total * 2" or "f(1)", in
+// the global scope and returns its value. Errors are reported as by Run.
+func (in *Interpreter) Eval(expr string) (Value, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	var errs ErrorList
+	p := parser.New(in.scan(expr, &errs), errs.addTokenError)
+	parsed := p.ParseExpression()
+	if errs != nil {
+		return Value{}, errs
+	}
+	interpreter.NewResolver(in.interp, errs.addTokenError).ResolveExpr(parsed)
+	if errs != nil {
+		return Value{}, errs
+	}
+	result, err := in.interp.Evaluate(parsed)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{v: result, owner: in}, nil
+}
+
+// compile turns source into a resolved program.
+func (in *Interpreter) compile(source string) ([]ast.Stmt, error) {
+	var errs ErrorList
+	statements := parser.New(in.scan(source, &errs), errs.addTokenError).Parse()
+	if errs != nil {
+		return nil, errs
+	}
+	interpreter.NewResolver(in.interp, errs.addTokenError).Resolve(statements)
+	if errs != nil {
+		return nil, errs
+	}
+	return statements, nil
+}
+
+// scan tokenizes source, appending lexical errors to errs.
+func (in *Interpreter) scan(source string, errs *ErrorList) []token.Token {
+	var mode scanner.Mode
+	if in.config.dialect.BigInt {
+		mode |= scanner.BigInts
+	}
+	s := scanner.New(source, func(file string, line, column int, message string) {
+		*errs = append(*errs, &Error{File: file, Line: line, Column: column, Message: message})
+	}, mode)
+	s.SetAliases(in.config.aliases)
+	// Includes read files, so they are allowed only along with the file
+	// natives, and are audited like them.
+	if in.config.fileSystem {
+		s.SetIncludeReader(in.interp.ReadInclude)
+	} else {
+		s.SetIncludeReader(nil)
+	}
+	return s.ScanTokens()
+}
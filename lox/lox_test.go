@@ -0,0 +1,71 @@
+package lox
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/scanner"
+)
+
+func TestKeywordAliases(t *testing.T) {
+	aliases, err := scanner.NewAliases(map[string]string{
+		"clase": "class", "funcion": "fun", "este": "this", "padre": "super",
+		"devolver": "return", "imprimir": "print", "mientras": "while", "romper": "break",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	in := New(WithStdout(&out), WithKeywordAliases(aliases))
+	err = in.Run(`
+		clase Animal { init(name) { este.name = name; } speak() { devolver este.name; } }
+		clase Dog < Animal { speak() { devolver padre.speak() + " woofs"; } }
+		imprimir Dog("Rex").speak();
+		funcion count() { var n = 0; mientras (true) { n = n + 1; if (n == 3) romper; } devolver n; }
+		print count();`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Rex woofs\n3\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	err = in.Run(`romper;`)
+	errs, ok := err.(ErrorList)
+	if !ok || len(errs) != 1 || errs[0].Error() != "[line 1, col 1] Error at 'romper': Can't use 'break' outside of a loop." {
+		t.Errorf("got %v, want the error to quote the alias", err)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	lib := filepath.Join(t.TempDir(), "lib.lox")
+	if err := os.WriteFile(lib, []byte(`var greeting = "hi";`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	source := "//#include " + strconv.Quote(lib) + "\nprint greeting;"
+
+	err := New(WithStdout(io.Discard)).Run(source)
+	errs, ok := err.(ErrorList)
+	if !ok || len(errs) == 0 || errs[0].Message != "Includes are disabled." {
+		t.Errorf("without the file system: got %v, want includes to be disabled", err)
+	}
+
+	var out, audit bytes.Buffer
+	err = New(WithStdout(&out), WithFileSystem(), WithAuditLog(&audit)).Run(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "hi\n" {
+		t.Errorf("got %q, want %q", out.String(), "hi\n")
+	}
+	args, _ := json.Marshal([]string{lib})
+	if !strings.Contains(audit.String(), `"capability":"fs","native":"include","args":`+string(args)+`,"line":1,"column":1`) {
+		t.Errorf("audit log %q doesn't record the include", audit.String())
+	}
+}
@@ -0,0 +1,90 @@
+package lox
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+// NativeFunc is a Go function callable from Lox. A returned error becomes
+// a Lox runtime error reported at the call.
+type NativeFunc func(args []Value) (Value, error)
+
+// RegisterNative defines a global function name that calls fn. Natives
+// accept any number of arguments; fn should check the ones it needs.
+// RegisterNative replaces any global already called name.
+func (in *Interpreter) RegisterNative(name string, fn NativeFunc) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.interp.DefineNative(name, -1, func(_ *interpreter.Interpreter, arguments []any) (any, error) {
+		args := make([]Value, len(arguments))
+		for i, argument := range arguments {
+			args[i] = Value{v: argument, owner: in}
+		}
+		var result Value
+		var err error
+		if failed := in.callOut(nil, func() { result, err = fn(args) }); failed != nil {
+			return nil, failed
+		}
+		if err != nil {
+			return nil, err
+		}
+		return in.adopt(result.v), nil
+	})
+}
+
+// Define binds name to value in the global scope, replacing any existing
+// global of that name.
+func (in *Interpreter) Define(name string, value Value) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.interp.Define(name, in.adopt(value.v))
+}
+
+// ValueOf converts a Go value to a Lox value. It accepts nil, bool,
+// string, every integer and floating-point type, and Value itself.
+// Integers are converted to float64, as Lox has a single number type.
+//
+// A struct, or a pointer to one, becomes an object whose exported fields
+// and methods scripts can use as properties; see the package example.
+// Pass a pointer for assignments from Lox to be visible to Go.
+//
+// ValueOf panics on any other type.
+func ValueOf(x any) Value {
+	switch x := x.(type) {
+	case nil, bool, string, float64:
+		return Value{v: x}
+	case Value:
+		return x
+	case float32:
+		return Value{v: float64(x)}
+	case int:
+		return Value{v: float64(x)}
+	case int8:
+		return Value{v: float64(x)}
+	case int16:
+		return Value{v: float64(x)}
+	case int32:
+		return Value{v: float64(x)}
+	case int64:
+		return Value{v: float64(x)}
+	case uint:
+		return Value{v: float64(x)}
+	case uint8:
+		return Value{v: float64(x)}
+	case uint16:
+		return Value{v: float64(x)}
+	case uint32:
+		return Value{v: float64(x)}
+	case uint64:
+		return Value{v: float64(x)}
+	}
+	if rv := reflect.ValueOf(x); isStruct(rv.Type()) {
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return Value{}
+		}
+		return Value{v: wrapStruct(rv, nil)}
+	}
+	panic(fmt.Sprintf("lox: cannot convert %T to a Lox value", x))
+}
@@ -0,0 +1,104 @@
+package lox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+func TestRegisterNative(t *testing.T) {
+	sum := func(args []Value) (Value, error) {
+		total := 0.0
+		for _, arg := range args {
+			n, ok := arg.AsNumber()
+			if !ok {
+				return Value{}, errors.New("sum takes numbers.")
+			}
+			total += n
+		}
+		return ValueOf(total), nil
+	}
+	// apply calls its first argument, a Lox function, with the rest.
+	apply := func(args []Value) (Value, error) {
+		fn, ok := args[0].AsCallable()
+		if !ok {
+			return Value{}, errors.New("apply takes a function.")
+		}
+		rest := make([]any, len(args)-1)
+		for n, arg := range args[1:] {
+			rest[n] = arg
+		}
+		return fn(rest...)
+	}
+	tests := []struct {
+		name, source, want, err string
+	}{
+		{name: "any number of arguments", source: `print sum(); print sum(1, 2, 3.5);`, want: "0\n6.5\n"},
+		{name: "error at the call", source: `print sum(1, "2");`, err: "sum takes numbers."},
+		{name: "calling back into Lox", source: `print apply(fun (a, b) { return a * b; }, 6, 7);`, want: "42\n"},
+		{name: "callback error", source: `apply(fun (a) { return a + nil; }, 1);`, err: "Operands must be two numbers or two strings."},
+		{name: "callback arity", source: `apply(fun (a) { return a; });`, err: "Expected 1 arguments but got 0."},
+		{name: "replaces a global", source: `print clock(1, 2);`, want: "3\n"},
+		{name: "returns Go objects", source: `var p = point(); p.x = 5; print p.x + p.y;`, want: "7\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			in := New(WithStdout(&out))
+			in.RegisterNative("sum", sum)
+			in.RegisterNative("apply", apply)
+			in.RegisterNative("clock", sum)
+			in.RegisterNative("point", func([]Value) (Value, error) {
+				return ValueOf(&struct{ X, Y int }{1, 2}), nil
+			})
+			err := in.Run(test.source)
+			var rt *interpreter.RuntimeError
+			switch {
+			case test.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.err != "" && (!errors.As(err, &rt) || rt.Message != test.err):
+				t.Errorf("got error %v, want a runtime error %q", err, test.err)
+			}
+			if out.String() != test.want {
+				t.Errorf("got %q, want %q", out.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestValueOf(t *testing.T) {
+	type point struct{ X, Y int }
+	tests := []struct {
+		value any
+		want  Kind
+	}{
+		{nil, Nil},
+		{true, Bool},
+		{"s", String},
+		{1.5, Number},
+		{float32(1.5), Number},
+		{int8(-3), Number},
+		{uint64(1 << 40), Number},
+		{ValueOf("v"), String},
+		{point{1, 2}, Instance},
+		{&point{1, 2}, Instance},
+		{(*point)(nil), Nil},
+	}
+	for _, test := range tests {
+		if got := ValueOf(test.value).Kind(); got != test.want {
+			t.Errorf("ValueOf(%#v): got %s, want %s", test.value, got, test.want)
+		}
+	}
+	if n, _ := ValueOf(uint16(7)).AsNumber(); n != 7 {
+		t.Errorf("got %v, want 7", n)
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "cannot convert []int") {
+			t.Errorf("got panic %v, want a conversion error", r)
+		}
+	}()
+	ValueOf([]int{1})
+}
@@ -0,0 +1,130 @@
+package lox
+
+import (
+	"io"
+	"log"
+
+	"github.com/kriyanshii/interpreter-go/scanner"
+)
+
+// Option configures an Interpreter created by New.
+type Option func(*config)
+
+type config struct {
+	stdout      io.Writer
+	strict      bool
+	dialect     Dialect
+	maxDepth    int
+	mutationLog int
+	network     bool
+	fileSystem  bool
+	graphics    bool
+	terminal    bool
+	signals     bool
+	aliases     scanner.Aliases
+	auditLog    io.Writer
+	// callbackErrors receives the errors of Lox callbacks that fail with
+	// no script running to report them to.
+	callbackErrors func(error)
+}
+
+// Dialect is a set of opt-in changes to the language. The zero Dialect is
+// standard Lox.
+type Dialect struct {
+	// BigInt makes integer arithmetic exact beyond 2^53: integral results
+	// that a float64 cannot hold are promoted to arbitrary precision.
+	BigInt bool
+}
+
+// WithStdout sends the output of print statements to w instead of
+// os.Stdout.
+func WithStdout(w io.Writer) Option {
+	return func(c *config) { c.stdout = w }
+}
+
+// WithStrict makes reading a variable that was declared without an
+// initializer, and not assigned since, a runtime error instead of
+// yielding nil.
+func WithStrict() Option {
+	return func(c *config) { c.strict = true }
+}
+
+// WithKeywordAliases lets scripts write the words in aliases in place of
+// the keywords they stand for, such as "si" for "if". Build aliases with
+// scanner.NewAliases.
+func WithKeywordAliases(aliases scanner.Aliases) Option {
+	return func(c *config) { c.aliases = aliases }
+}
+
+// WithCallbackErrors calls handle with the error of a Lox function that
+// was converted to a Go func with no error result and failed outside the
+// Go method it was passed to, as when Go code stores the function and
+// calls it later. There is no script to fail then, so by default the error
+// is only logged, with the standard log package.
+func WithCallbackErrors(handle func(error)) Option {
+	return func(c *config) { c.callbackErrors = handle }
+}
+
+// logCallbackError is the default handler for WithCallbackErrors.
+func logCallbackError(err error) {
+	log.Printf("lox: callback failed: %v", err)
+}
+
+// WithDialect enables the language extensions in d.
+func WithDialect(d Dialect) Option {
+	return func(c *config) { c.dialect = d }
+}
+
+// WithMaxDepth limits how deeply function calls may nest; deeper calls
+// fail with a "Stack overflow." runtime error. Zero removes the limit.
+func WithMaxDepth(n int) Option {
+	return func(c *config) { c.maxDepth = n }
+}
+
+// WithMutationLog records the last size variable definitions and
+// assignments, for inspection through MutationLog.
+func WithMutationLog(size int) Option {
+	return func(c *config) { c.mutationLog = size }
+}
+
+// WithNetwork defines the socket natives tcpConnect, wsConnect, send,
+// recvLine, onReceive and close, which scripts cannot use otherwise.
+func WithNetwork() Option {
+	return func(c *config) { c.network = true }
+}
+
+// WithFileSystem defines the file natives readFile, writeFile, listDir,
+// joinPath, basename, dirname, mkdir, remove and stat, and allows
+// //#include directives, which scripts cannot use otherwise. Relative
+// include paths are resolved against the working directory, as the
+// natives' paths are.
+func WithFileSystem() Option {
+	return func(c *config) { c.fileSystem = true }
+}
+
+// WithGraphics defines the canvas natives canvasNew, setColor, drawLine,
+// savePNG and saveSVG.
+func WithGraphics() Option {
+	return func(c *config) { c.graphics = true }
+}
+
+// WithAuditLog writes a line of JSON to w for every call a script makes
+// to the network, file system or file-saving graphics natives, recording
+// the native, its arguments, the time and where in the script it was
+// called. See interpreter.AuditLog.
+func WithAuditLog(w io.Writer) Option {
+	return func(c *config) { c.auditLog = w }
+}
+
+// WithTerminal defines the terminal natives termClear, termMoveTo,
+// termColor and readKey. readKey reads from os.Stdin.
+func WithTerminal() Option {
+	return func(c *config) { c.terminal = true }
+}
+
+// WithSignals defines onSignal, which lets scripts trap SIGINT, SIGTERM
+// and SIGHUP. Trapping a signal takes it from the host process for as
+// long as the script has a handler for it.
+func WithSignals() Option {
+	return func(c *config) { c.signals = true }
+}
@@ -0,0 +1,85 @@
+package lox
+
+import (
+	"io"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+// Pool runs many short scripts, such as submissions to grade, without
+// paying for a new interpreter and prelude for each one. It keeps
+// interpreters ready with the prelude already run, and runs each script on
+// one of them; once the script is done, the interpreter is reset to just
+// after the prelude, in the background, and can take the next one. No
+// script sees what another one defined or changed.
+//
+//	pool, err := lox.NewPool(8, prelude, lox.WithMaxDepth(200))
+//	...
+//	var out bytes.Buffer
+//	err = pool.Run(submission, &out)
+//
+// A Pool may be used from several goroutines, and runs as many scripts at
+// once as it has interpreters.
+type Pool struct {
+	snapshot *interpreter.Snapshot
+	idle     chan *pooled
+}
+
+// pooled is an interpreter kept by a Pool, with a stdout that each run
+// points at the writer it was given.
+type pooled struct {
+	in     *Interpreter
+	stdout *switchWriter
+}
+
+// switchWriter writes to w, which can be changed between runs.
+type switchWriter struct {
+	w io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+// NewPool creates size interpreters, at least one, configured by opts,
+// other than WithStdout, which Run replaces. It runs prelude, if not
+// empty, to set up what every script may use, and fails if the prelude
+// does.
+func NewPool(size int, prelude string, opts ...Option) (*Pool, error) {
+	size = max(size, 1)
+	p := &Pool{idle: make(chan *pooled, size)}
+	for n := 0; n < size; n++ {
+		stdout := &switchWriter{w: io.Discard}
+		in := New(append(opts[:len(opts):len(opts)], WithStdout(stdout))...)
+		if n == 0 {
+			// Run the prelude once and copy what it left behind into the
+			// other interpreters.
+			if err := in.Run(prelude); err != nil {
+				return nil, err
+			}
+			p.snapshot = in.interp.Snapshot()
+		} else {
+			in.interp.Reset(p.snapshot)
+		}
+		p.idle <- &pooled{in: in, stdout: stdout}
+	}
+	return p, nil
+}
+
+// Run runs source as Interpreter.Run would, on the next interpreter that
+// is free, with print writing to stdout. It waits while every interpreter
+// is busy.
+func (p *Pool) Run(source string, stdout io.Writer) error {
+	worker := <-p.idle
+	worker.stdout.w = stdout
+	err := worker.in.Run(source)
+	worker.stdout.w = io.Discard
+
+	go func() {
+		worker.in.mu.Lock()
+		worker.in.interp.Reset(p.snapshot)
+		worker.in.mu.Unlock()
+		p.idle <- worker
+	}()
+	return err
+}
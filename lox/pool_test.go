@@ -0,0 +1,103 @@
+package lox
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+const counterPrelude = `
+class Counter {
+  init() { this.n = 0; }
+}
+var counter = Counter();
+fun bump() {
+  counter.n = counter.n + 1;
+  return counter.n;
+}`
+
+// run runs source on pool and returns what it printed.
+func run(t *testing.T, pool *Pool, source string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := pool.Run(source, &out); err != nil {
+		t.Fatalf("%q: %v", source, err)
+	}
+	return out.String()
+}
+
+func TestPoolResetsGlobals(t *testing.T) {
+	pool, err := NewPool(1, counterPrelude)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, pool, `var leaked = 1; fun bump() { return "replaced"; }`)
+	var out bytes.Buffer
+	err = pool.Run(`print leaked;`, &out)
+	if rt, ok := err.(*interpreter.RuntimeError); !ok || rt.Message != "Undefined variable 'leaked'." {
+		t.Errorf("a global defined by an earlier run: got %v, want an undefined variable error", err)
+	}
+	if got := run(t, pool, `print bump();`); got != "1\n" {
+		t.Errorf("a function redefined by an earlier run: got %q, want %q", got, "1\n")
+	}
+}
+
+func TestPoolResetsPreludeObjects(t *testing.T) {
+	pool, err := NewPool(1, counterPrelude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < 3; n++ {
+		if got := run(t, pool, `bump(); print bump();`); got != "2\n" {
+			t.Fatalf("run %d: got %q, want %q", n, got, "2\n")
+		}
+	}
+	run(t, pool, `counter = nil;`)
+	if got := run(t, pool, `print bump();`); got != "1\n" {
+		t.Errorf("after a run replaced counter: got %q, want %q", got, "1\n")
+	}
+}
+
+func TestPoolDropsTimers(t *testing.T) {
+	pool, err := NewPool(1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, pool, `fun late() { print "late"; } setTimeout(late, 0);`)
+	if got := run(t, pool, `runLoop(); print "done";`); got != "done\n" {
+		t.Errorf("got %q, want %q", got, "done\n")
+	}
+}
+
+func TestPoolConcurrentRuns(t *testing.T) {
+	pool, err := NewPool(4, counterPrelude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for n := 0; n < 50; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out bytes.Buffer
+			if err := pool.Run(`print bump();`, &out); err != nil {
+				t.Error(err)
+			} else if out.String() != "1\n" {
+				t.Errorf("got %q, want %q", out.String(), "1\n")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPoolPreludeError(t *testing.T) {
+	if _, err := NewPool(2, `print missing;`); err == nil {
+		t.Error("NewPool succeeded with a failing prelude")
+	}
+	if _, err := NewPool(2, `var;`); err == nil {
+		t.Error("NewPool succeeded with a prelude that doesn't compile")
+	}
+}
@@ -0,0 +1,149 @@
+package lox
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/kriyanshii/interpreter-go/interpreter"
+)
+
+// Kind is the type of a Lox value.
+type Kind int
+
+const (
+	Nil Kind = iota
+	Bool
+	Number
+	String
+	Function // functions and natives
+	Class
+	Instance
+	List
+	Map
+)
+
+var kindNames = [...]string{
+	Nil:      "nil",
+	Bool:     "bool",
+	Number:   "number",
+	String:   "string",
+	Function: "function",
+	Class:    "class",
+	Instance: "instance",
+	List:     "list",
+	Map:      "map",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Value is a Lox value returned to Go. The zero Value is nil.
+type Value struct {
+	v any
+	// owner is the interpreter the value came from, if any, which runs
+	// it when it is a function called through AsCallable.
+	owner *Interpreter
+}
+
+// Kind reports which type of Lox value v holds.
+func (v Value) Kind() Kind {
+	switch v.v.(type) {
+	case nil:
+		return Nil
+	case bool:
+		return Bool
+	case float64, *big.Int:
+		return Number
+	case string:
+		return String
+	case *interpreter.LoxClass:
+		return Class
+	case *interpreter.LoxList:
+		return List
+	case *interpreter.LoxMap:
+		return Map
+	case *interpreter.LoxInstance, interpreter.Object:
+		return Instance
+	default:
+		return Function
+	}
+}
+
+// IsNil reports whether v is nil.
+func (v Value) IsNil() bool {
+	return v.v == nil
+}
+
+// AsBool returns v if it is a boolean.
+func (v Value) AsBool() (b, ok bool) {
+	b, ok = v.v.(bool)
+	return b, ok
+}
+
+// AsNumber returns v if it is a number. Integers held with arbitrary
+// precision under the bigint dialect are rounded to the nearest float64.
+func (v Value) AsNumber() (float64, bool) {
+	switch n := v.v.(type) {
+	case float64:
+		return n, true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+// AsBigInt returns v as an exact integer if it is an integral number.
+func (v Value) AsBigInt() (*big.Int, bool) {
+	switch n := v.v.(type) {
+	case *big.Int:
+		return new(big.Int).Set(n), true
+	case float64:
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			i, _ := big.NewFloat(n).Int(nil)
+			return i, true
+		}
+	}
+	return nil, false
+}
+
+// AsString returns v if it is a string. Use String to format a value of
+// any kind.
+func (v Value) AsString() (string, bool) {
+	s, ok := v.v.(string)
+	return s, ok
+}
+
+// AsCallable returns a Go function that calls v, if v is a Lox function,
+// class or native that came from an Interpreter. Arguments are converted
+// with ValueOf. The function may be called from any goroutine, at any
+// time: it waits for the interpreter to be free, as described on
+// Interpreter, so a Lox callback can be handed to event handlers or to Go
+// code that a native calls.
+func (v Value) AsCallable() (func(args ...any) (Value, error), bool) {
+	callable, ok := v.v.(interpreter.LoxCallable)
+	if !ok || v.owner == nil {
+		return nil, false
+	}
+	in := v.owner
+	return func(args ...any) (result Value, err error) {
+		arguments := make([]any, len(args))
+		for i, arg := range args {
+			arguments[i] = in.adopt(ValueOf(arg).v)
+		}
+		in.callBack(func() { result, err = in.call(callable, arguments) })
+		return result, err
+	}, true
+}
+
+// Interface returns the underlying Go representation of v: nil, bool,
+// float64, string, *big.Int, or one of the interpreter's object types.
+func (v Value) Interface() any {
+	return v.v
+}
+
+// String formats v the way print shows it.
+func (v Value) String() string {
+	return interpreter.Stringify(v.v)
+}
@@ -0,0 +1,30 @@
+package lox
+
+import "testing"
+
+func TestKind(t *testing.T) {
+	in := New()
+	if err := in.Run(`class Point {} fun f() {}`); err != nil {
+		t.Fatal(err)
+	}
+	for expr, want := range map[string]Kind{
+		`nil`:     Nil,
+		`true`:    Bool,
+		`1.5`:     Number,
+		`"s"`:     String,
+		`f`:       Function,
+		`clock`:   Function,
+		`Point`:   Class,
+		`Point()`: Instance,
+		`list(1)`: List,
+		`map()`:   Map,
+	} {
+		v, err := in.Eval(expr)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if v.Kind() != want {
+			t.Errorf("%s: got %s, want %s", expr, v.Kind(), want)
+		}
+	}
+}
@@ -0,0 +1,873 @@
+// Package parser builds a Lox syntax tree from tokens.
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// Parser is a recursive-descent parser over the tokens produced by the
+// Scanner. Each grammar rule is a method, from lowest to highest
+// precedence:
+//
+//	program     → declaration* EOF ;
+//	declaration → classDecl | funDecl | varDecl | statement ;
+//	classDecl   → "class" IDENTIFIER ( "<" IDENTIFIER )? "{" function* "}" ;
+//	funDecl     → "fun" function ;
+//	function    → IDENTIFIER "(" parameters? ")" block ;
+//	parameters  → IDENTIFIER ( "," IDENTIFIER )* ;
+//	varDecl     → "var" IDENTIFIER ( "=" expression )? ";" ;
+//	statement   → exprStmt | forStmt | ifStmt | printStmt | returnStmt
+//	            | whileStmt | breakStmt | continueStmt | block ;
+//	forStmt     → "for" "(" ( varDecl | exprStmt | ";" )
+//	              expression? ";" expression? ")" statement ;
+//	ifStmt      → "if" "(" expression ")" statement ( "else" statement )? ;
+//	printStmt   → "print" expression ";" ;
+//	returnStmt  → "return" expression? ";" ;
+//	whileStmt   → "while" "(" expression ")" statement ;
+//	breakStmt   → "break" ";" ;
+//	continueStmt → "continue" ";" ;
+//	block       → "{" declaration* "}" ;
+//	exprStmt    → expression ";" ;
+//
+//	expression  → assignment ;
+//	assignment  → ( call "." )? IDENTIFIER
+//	              ( "=" | "+=" | "-=" | "*=" | "/=" | "%=" ) assignment
+//	            | conditional ;
+//	conditional → logic_or ( "?" expression ":" conditional )? ;
+//	logic_or   → logic_and ( "or" logic_and )* ;
+//	logic_and  → equality ( "and" equality )* ;
+//	equality   → comparison ( ( "!=" | "==" ) comparison )* ;
+//	comparison → term ( ( ">" | ">=" | "<" | "<=" ) term )* ;
+//	term       → factor ( ( "-" | "+" ) factor )* ;
+//	factor     → unary ( ( "/" | "*" | "%" ) unary )* ;
+//	unary      → ( "!" | "-" | "++" | "--" ) unary | postfix ;
+//	postfix    → call ( "++" | "--" )? ;
+//	call       → primary ( "(" arguments? ")" | "." IDENTIFIER )* ;
+//	arguments  → expression ( "," expression )* ;
+//	primary    → NUMBER | STRING | "true" | "false" | "nil" | "this"
+//	           | IDENTIFIER | "(" expression ")" | "super" "." IDENTIFIER
+//	           | "fun" "(" parameters? ")" block
+//	           | "(" parameters? ")" "=>" expression
+//	           | INTERPOLATION expression ( INTERPOLATION expression )* STRING ;
+type Parser struct {
+	err      token.ErrorHandler
+	tokens   []token.Token
+	current  int
+	hadError bool
+}
+
+// parseError unwinds the parser after a syntax error has been reported.
+type parseError struct{}
+
+func (parseError) Error() string { return "parse error" }
+
+// New returns a parser over tokens, which must end with an EOF token, that
+// reports syntax errors to err.
+func New(tokens []token.Token, err token.ErrorHandler) *Parser {
+	return &Parser{err: err, tokens: tokens}
+}
+
+// Parse parses a whole program. After a syntax error it skips to the next
+// statement and carries on, so that one pass reports every error; it
+// returns nil if any were reported.
+func (p *Parser) Parse() []ast.Stmt {
+	var statements []ast.Stmt
+	for !p.isAtEnd() {
+		if stmt := p.declaration(); stmt != nil {
+			statements = append(statements, stmt)
+		}
+	}
+	if p.hadError {
+		return nil
+	}
+	return statements
+}
+
+// ParseExpression parses source consisting of a single expression. It
+// returns nil if a syntax error was reported.
+func (p *Parser) ParseExpression() ast.Expr {
+	expr, err := p.expression()
+	if err != nil {
+		return nil
+	}
+	if !p.isAtEnd() {
+		p.error(p.peek(), "Expect end of expression.")
+		return nil
+	}
+	return expr
+}
+
+// maxArguments is the most parameters a function may declare and the most
+// arguments a call may pass.
+const maxArguments = 255
+
+// declaration parses a declaration, or after a syntax error synchronizes
+// and returns nil.
+func (p *Parser) declaration() ast.Stmt {
+	stmt, err := p.tryDeclaration()
+	if err != nil {
+		p.synchronize()
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) tryDeclaration() (ast.Stmt, error) {
+	switch {
+	case p.match(token.Class):
+		return p.classDeclaration()
+	case p.check(token.Fun) && p.checkNext(token.Identifier):
+		p.advance()
+		return p.function("function")
+	case p.match(token.Var):
+		return p.varDeclaration()
+	}
+	return p.statement()
+}
+
+func (p *Parser) classDeclaration() (ast.Stmt, error) {
+	name, err := p.consume(token.Identifier, "Expect class name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *ast.VariableExpr
+	if p.match(token.Less) {
+		if _, err := p.consume(token.Identifier, "Expect superclass name."); err != nil {
+			return nil, err
+		}
+		superclass = &ast.VariableExpr{Name: p.previous()}
+	}
+
+	if _, err := p.consume(token.LeftBrace, "Expect '{' before class body."); err != nil {
+		return nil, err
+	}
+	var methods []*ast.FunctionStmt
+	for !p.check(token.RightBrace) && !p.isAtEnd() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+	if _, err := p.consume(token.RightBrace, "Expect '}' after class body."); err != nil {
+		return nil, err
+	}
+	return &ast.ClassStmt{Name: name, Superclass: superclass, Methods: methods}, nil
+}
+
+// function parses the name, parameters and body of a function. kind names
+// what is being declared in error messages.
+func (p *Parser) function(kind string) (*ast.FunctionStmt, error) {
+	name, err := p.consume(token.Identifier, "Expect "+kind+" name.")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.LeftParen, "Expect '(' after "+kind+" name."); err != nil {
+		return nil, err
+	}
+	params, body, err := p.functionBody(kind)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.FunctionStmt{Name: name, Params: params, Body: body}, nil
+}
+
+// functionBody parses the parameters, whose "(" has been consumed, and the
+// body of a function.
+func (p *Parser) functionBody(kind string) ([]token.Token, []ast.Stmt, error) {
+	params, err := p.parameters()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := p.consume(token.LeftBrace, "Expect '{' before "+kind+" body."); err != nil {
+		return nil, nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, nil, err
+	}
+	return params, body, nil
+}
+
+// parameters parses a parameter list and its closing ")".
+func (p *Parser) parameters() ([]token.Token, error) {
+	var params []token.Token
+	if !p.check(token.RightParen) {
+		for {
+			if len(params) >= maxArguments {
+				p.error(p.peek(), fmt.Sprintf("Can't have more than %d parameters.", maxArguments))
+			}
+			param, err := p.consume(token.Identifier, "Expect parameter name.")
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, param)
+			if !p.match(token.Comma) {
+				break
+			}
+		}
+	}
+	if _, err := p.consume(token.RightParen, "Expect ')' after parameters."); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+func (p *Parser) varDeclaration() (ast.Stmt, error) {
+	name, err := p.consume(token.Identifier, "Expect variable name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var initializer ast.Expr
+	if p.match(token.Equal) {
+		if initializer, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(token.Semicolon, "Expect ';' after variable declaration."); err != nil {
+		return nil, err
+	}
+	return &ast.VarStmt{Name: name, Initializer: initializer}, nil
+}
+
+func (p *Parser) statement() (ast.Stmt, error) {
+	switch {
+	case p.match(token.Break, token.Continue):
+		return p.jumpStatement()
+	case p.match(token.For):
+		return p.forStatement()
+	case p.match(token.If):
+		return p.ifStatement()
+	case p.match(token.Print):
+		return p.printStatement()
+	case p.match(token.Return):
+		return p.returnStatement()
+	case p.match(token.While):
+		return p.whileStatement()
+	case p.match(token.LeftBrace):
+		statements, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		return &ast.BlockStmt{Statements: statements}, nil
+	}
+	return p.expressionStatement()
+}
+
+// jumpStatement parses the rest of a break or continue statement.
+func (p *Parser) jumpStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	if _, err := p.consume(token.Semicolon, "Expect ';' after '"+keyword.Lexeme+"'."); err != nil {
+		return nil, err
+	}
+	if keyword.Type == token.Break {
+		return &ast.BreakStmt{Keyword: keyword}, nil
+	}
+	return &ast.ContinueStmt{Keyword: keyword}, nil
+}
+
+// forStatement desugars a C-style for loop into an optional initializer
+// followed by a while loop that keeps the increment.
+func (p *Parser) forStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	if _, err := p.consume(token.LeftParen, "Expect '(' after 'for'."); err != nil {
+		return nil, err
+	}
+
+	var initializer ast.Stmt
+	var err error
+	switch {
+	case p.match(token.Semicolon):
+	case p.match(token.Var):
+		initializer, err = p.varDeclaration()
+	default:
+		initializer, err = p.expressionStatement()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var condition ast.Expr
+	if !p.check(token.Semicolon) {
+		if condition, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(token.Semicolon, "Expect ';' after loop condition."); err != nil {
+		return nil, err
+	}
+
+	var increment ast.Expr
+	if !p.check(token.RightParen) {
+		if increment, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(token.RightParen, "Expect ')' after for clauses."); err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	if condition == nil {
+		condition = &ast.LiteralExpr{Value: true, File: keyword.File, Line: keyword.Line}
+	}
+	body = &ast.WhileStmt{Condition: condition, Body: body, Increment: increment}
+	if initializer != nil {
+		body = &ast.BlockStmt{Statements: []ast.Stmt{initializer, body}}
+	}
+	return body, nil
+}
+
+func (p *Parser) ifStatement() (ast.Stmt, error) {
+	condition, err := p.parenthesized("if")
+	if err != nil {
+		return nil, err
+	}
+	thenBranch, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	var elseBranch ast.Stmt
+	if p.match(token.Else) {
+		if elseBranch, err = p.statement(); err != nil {
+			return nil, err
+		}
+	}
+	return &ast.IfStmt{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}, nil
+}
+
+func (p *Parser) printStatement() (ast.Stmt, error) {
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.Semicolon, "Expect ';' after value."); err != nil {
+		return nil, err
+	}
+	return &ast.PrintStmt{Expression: value}, nil
+}
+
+func (p *Parser) returnStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	var value ast.Expr
+	if !p.check(token.Semicolon) {
+		var err error
+		if value, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(token.Semicolon, "Expect ';' after return value."); err != nil {
+		return nil, err
+	}
+	return &ast.ReturnStmt{Keyword: keyword, Value: value}, nil
+}
+
+func (p *Parser) whileStatement() (ast.Stmt, error) {
+	condition, err := p.parenthesized("while")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	return &ast.WhileStmt{Condition: condition, Body: body}, nil
+}
+
+// parenthesized parses the "( expression )" that follows keyword.
+func (p *Parser) parenthesized(keyword string) (ast.Expr, error) {
+	if _, err := p.consume(token.LeftParen, "Expect '(' after '"+keyword+"'."); err != nil {
+		return nil, err
+	}
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.RightParen, "Expect ')' after "+keyword+" condition."); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// block parses the declarations up to the closing brace. The opening brace
+// has already been consumed.
+func (p *Parser) block() ([]ast.Stmt, error) {
+	var statements []ast.Stmt
+	for !p.check(token.RightBrace) && !p.isAtEnd() {
+		if stmt := p.declaration(); stmt != nil {
+			statements = append(statements, stmt)
+		}
+	}
+	if _, err := p.consume(token.RightBrace, "Expect '}' after block."); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+func (p *Parser) expressionStatement() (ast.Stmt, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.Semicolon, "Expect ';' after expression."); err != nil {
+		return nil, err
+	}
+	return &ast.ExpressionStmt{Expression: expr}, nil
+}
+
+func (p *Parser) expression() (ast.Expr, error) {
+	return p.assignment()
+}
+
+func (p *Parser) assignment() (ast.Expr, error) {
+	expr, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.PlusEqual, token.MinusEqual, token.StarEqual, token.SlashEqual, token.PercentEqual) {
+		return p.compoundAssignment(expr)
+	}
+	if p.match(token.Equal) {
+		equals := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		switch target := expr.(type) {
+		case *ast.VariableExpr:
+			return &ast.AssignExpr{Name: target.Name, Value: value}, nil
+		case *ast.GetExpr:
+			return &ast.SetExpr{Object: target.Object, Name: target.Name, Value: value}, nil
+		}
+		// Report but don't unwind: the parser is not confused, the target
+		// just isn't assignable.
+		p.error(equals, "Invalid assignment target.")
+	}
+	return expr, nil
+}
+
+// compoundOperators maps each compound assignment operator to the binary
+// operator it applies.
+var compoundOperators = map[token.Type]token.Type{
+	token.PlusEqual:    token.Plus,
+	token.MinusEqual:   token.Minus,
+	token.StarEqual:    token.Star,
+	token.SlashEqual:   token.Slash,
+	token.PercentEqual: token.Percent,
+}
+
+// compoundAssignment parses the rest of "target += value" and the like,
+// whose operator has been consumed, desugaring it into
+// "target = target + value". A field's object is evaluated once for each
+// half, so it must be free of side effects: a variable, "this", or a field
+// of one of those.
+func (p *Parser) compoundAssignment(target ast.Expr) (ast.Expr, error) {
+	operator := p.previous()
+	value, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+	binary := operator
+	binary.Type = compoundOperators[operator.Type]
+	binary.Lexeme = strings.TrimSuffix(operator.Lexeme, "=")
+	binary.Length = len(binary.Lexeme)
+
+	switch target := target.(type) {
+	case *ast.VariableExpr:
+		current := &ast.VariableExpr{Name: target.Name}
+		return &ast.AssignExpr{Name: target.Name, Value: &ast.BinaryExpr{Left: current, Operator: binary, Right: value}}, nil
+	case *ast.GetExpr:
+		if !isPlace(target.Object) {
+			p.error(operator, "Can't use '"+operator.Lexeme+"' on a field of this object, which would be evaluated twice; store it in a variable first.")
+			return target, nil
+		}
+		current := &ast.GetExpr{Object: target.Object, Name: target.Name}
+		return &ast.SetExpr{Object: target.Object, Name: target.Name, Value: &ast.BinaryExpr{Left: current, Operator: binary, Right: value}}, nil
+	}
+	p.error(operator, "Invalid assignment target.")
+	return target, nil
+}
+
+// isPlace reports whether evaluating expr has no side effects: whether it
+// is a variable, "this", or a field of one of those.
+func isPlace(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.VariableExpr, *ast.ThisExpr:
+		return true
+	case *ast.GetExpr:
+		return isPlace(e.Object)
+	case *ast.GroupingExpr:
+		return isPlace(e.Expression)
+	}
+	return false
+}
+
+// conditional parses "condition ? a : b", which binds more loosely than
+// "or" and groups to the right, so that "a ? b : c ? d : e" means
+// "a ? b : (c ? d : e)". Like C, it takes any expression between the "?"
+// and the ":".
+func (p *Parser) conditional() (ast.Expr, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(token.Question) {
+		return expr, nil
+	}
+	question := p.previous()
+	thenBranch, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.Colon, "Expect ':' after then branch of conditional expression."); err != nil {
+		return nil, err
+	}
+	elseBranch, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	return &ast.ConditionalExpr{Condition: expr, Question: question, ThenBranch: thenBranch, ElseBranch: elseBranch}, nil
+}
+
+func (p *Parser) or() (ast.Expr, error) {
+	return p.logical(p.and, token.Or)
+}
+
+func (p *Parser) and() (ast.Expr, error) {
+	return p.logical(p.equality, token.And)
+}
+
+// logical parses a left-associative chain of short-circuiting operators.
+func (p *Parser) logical(operand func() (ast.Expr, error), operator token.Type) (ast.Expr, error) {
+	expr, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(operator) {
+		op := p.previous()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		expr = &ast.LogicalExpr{Left: expr, Operator: op, Right: right}
+	}
+	return expr, nil
+}
+
+func (p *Parser) equality() (ast.Expr, error) {
+	return p.binary(p.comparison, token.BangEqual, token.EqualEqual)
+}
+
+func (p *Parser) comparison() (ast.Expr, error) {
+	return p.binary(p.term, token.Greater, token.GreaterEqual, token.Less, token.LessEqual)
+}
+
+func (p *Parser) term() (ast.Expr, error) {
+	return p.binary(p.factor, token.Minus, token.Plus)
+}
+
+func (p *Parser) factor() (ast.Expr, error) {
+	return p.binary(p.unary, token.Slash, token.Star, token.Percent)
+}
+
+// binary parses a left-associative chain of operands separated by any of
+// the given operators.
+func (p *Parser) binary(operand func() (ast.Expr, error), operators ...token.Type) (ast.Expr, error) {
+	expr, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(operators...) {
+		operator := p.previous()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		expr = &ast.BinaryExpr{Left: expr, Operator: operator, Right: right}
+	}
+	return expr, nil
+}
+
+func (p *Parser) unary() (ast.Expr, error) {
+	if p.match(token.Bang, token.Minus) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &ast.UnaryExpr{Operator: operator, Right: right}, nil
+	}
+	if p.match(token.PlusPlus, token.MinusMinus) {
+		operator := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &ast.IncrementExpr{Operator: operator, Target: target, Prefix: true}, nil
+	}
+	return p.postfix()
+}
+
+// postfix parses "n++" and "n--". Like the prefix forms, it takes any
+// target; the resolver reports those that can't be assigned to.
+func (p *Parser) postfix() (ast.Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(token.PlusPlus, token.MinusMinus) {
+		return &ast.IncrementExpr{Operator: p.previous(), Target: expr}, nil
+	}
+	return expr, nil
+}
+
+func (p *Parser) call() (ast.Expr, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.match(token.LeftParen):
+			if expr, err = p.finishCall(expr); err != nil {
+				return nil, err
+			}
+		case p.match(token.Dot):
+			name, err := p.consume(token.Identifier, "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+			expr = &ast.GetExpr{Object: expr, Name: name}
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func (p *Parser) finishCall(callee ast.Expr) (ast.Expr, error) {
+	var arguments []ast.Expr
+	if !p.check(token.RightParen) {
+		for {
+			if len(arguments) >= maxArguments {
+				p.error(p.peek(), fmt.Sprintf("Can't have more than %d arguments.", maxArguments))
+			}
+			argument, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, argument)
+			if !p.match(token.Comma) {
+				break
+			}
+		}
+	}
+	paren, err := p.consume(token.RightParen, "Expect ')' after arguments.")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.CallExpr{Callee: callee, Paren: paren, Arguments: arguments}, nil
+}
+
+func (p *Parser) primary() (ast.Expr, error) {
+	switch {
+	case p.match(token.False):
+		return &ast.LiteralExpr{Value: false, File: p.previous().File, Line: p.previous().Line}, nil
+	case p.match(token.True):
+		return &ast.LiteralExpr{Value: true, File: p.previous().File, Line: p.previous().Line}, nil
+	case p.match(token.Nil):
+		return &ast.LiteralExpr{Value: nil, File: p.previous().File, Line: p.previous().Line}, nil
+	case p.match(token.Number, token.String):
+		return &ast.LiteralExpr{Value: p.previous().Literal, File: p.previous().File, Line: p.previous().Line}, nil
+	case p.match(token.Interpolation):
+		return p.interpolation()
+	case p.match(token.Super):
+		keyword := p.previous()
+		if _, err := p.consume(token.Dot, "Expect '.' after 'super'."); err != nil {
+			return nil, err
+		}
+		method, err := p.consume(token.Identifier, "Expect superclass method name.")
+		if err != nil {
+			return nil, err
+		}
+		return &ast.SuperExpr{Keyword: keyword, Method: method}, nil
+	case p.match(token.This):
+		return &ast.ThisExpr{Keyword: p.previous()}, nil
+	case p.match(token.Identifier):
+		return &ast.VariableExpr{Name: p.previous()}, nil
+	case p.match(token.Fun):
+		keyword := p.previous()
+		if _, err := p.consume(token.LeftParen, "Expect '(' after 'fun'."); err != nil {
+			return nil, err
+		}
+		params, body, err := p.functionBody("function")
+		if err != nil {
+			return nil, err
+		}
+		return &ast.FunctionExpr{Function: &ast.FunctionStmt{Name: keyword, Params: params, Body: body}}, nil
+	case p.check(token.LeftParen) && p.arrowAhead():
+		return p.arrowFunction()
+	case p.match(token.LeftParen):
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consume(token.RightParen, "Expect ')' after expression."); err != nil {
+			return nil, err
+		}
+		return &ast.GroupingExpr{Expression: expr}, nil
+	}
+	return nil, p.error(p.peek(), "Expect expression.")
+}
+
+// arrowAhead reports whether the next tokens are the parameters of an
+// arrow function, "(a, b) =>", rather than a parenthesized expression.
+func (p *Parser) arrowAhead() bool {
+	n := p.current + 1
+	if p.tokens[n].Type != token.RightParen {
+		for p.tokens[n].Type == token.Identifier {
+			n++
+			if p.tokens[n].Type != token.Comma {
+				break
+			}
+			n++
+		}
+		if p.tokens[n].Type != token.RightParen {
+			return false
+		}
+	}
+	return p.tokens[n+1].Type == token.Arrow
+}
+
+// arrowFunction parses "(a, b) => a + b", desugaring it into a function
+// that returns the expression after the arrow.
+func (p *Parser) arrowFunction() (ast.Expr, error) {
+	p.advance()
+	params, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	arrow, err := p.consume(token.Arrow, "Expect '=>' after parameters.")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	body := []ast.Stmt{&ast.ReturnStmt{Keyword: arrow, Value: value}}
+	return &ast.FunctionExpr{Function: &ast.FunctionStmt{Name: arrow, Params: params, Body: body}}, nil
+}
+
+// interpolation parses the rest of a string literal with embedded
+// expressions, whose first Interpolation token has been consumed.
+func (p *Parser) interpolation() (ast.Expr, error) {
+	expr := &ast.InterpolationExpr{Start: p.previous()}
+	for {
+		piece := p.previous()
+		if piece.Literal != "" {
+			expr.Parts = append(expr.Parts, &ast.LiteralExpr{Value: piece.Literal, File: piece.File, Line: piece.Line})
+		}
+		if piece.Type == token.String {
+			return expr, nil
+		}
+		// The string goes on straight after "${", as in "${}". Its tokens
+		// start at the '}', unlike those of a string nested inside.
+		if next := p.peek(); (next.Type == token.Interpolation || next.Type == token.String) && strings.HasPrefix(next.Lexeme, "}") {
+			return nil, p.error(p.peek(), "Expect expression in interpolation.")
+		}
+		part, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		expr.Parts = append(expr.Parts, part)
+		if !p.match(token.Interpolation, token.String) {
+			return nil, p.error(p.peek(), "Expect '}' after interpolated expression.")
+		}
+	}
+}
+
+func (p *Parser) match(types ...token.Type) bool {
+	for _, t := range types {
+		if p.check(t) {
+			p.advance()
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) consume(t token.Type, message string) (token.Token, error) {
+	if p.check(t) {
+		return p.advance(), nil
+	}
+	return token.Token{}, p.error(p.peek(), message)
+}
+
+func (p *Parser) check(t token.Type) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.peek().Type == t
+}
+
+// checkNext is check for the token after the next one.
+func (p *Parser) checkNext(t token.Type) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.tokens[p.current+1].Type == t
+}
+
+func (p *Parser) advance() token.Token {
+	if !p.isAtEnd() {
+		p.current++
+	}
+	return p.previous()
+}
+
+func (p *Parser) isAtEnd() bool {
+	return p.peek().Type == token.EOF
+}
+
+func (p *Parser) peek() token.Token {
+	return p.tokens[p.current]
+}
+
+func (p *Parser) previous() token.Token {
+	return p.tokens[p.current-1]
+}
+
+// error reports a syntax error at tok and returns the error that unwinds
+// to the enclosing declaration. Callers that can carry on ignore it.
+func (p *Parser) error(tok token.Token, message string) error {
+	p.hadError = true
+	p.err(tok, message)
+	return parseError{}
+}
+
+// synchronize discards tokens up to what is probably the start of the
+// next statement: just past a semicolon, or at a keyword that begins one.
+func (p *Parser) synchronize() {
+	p.advance()
+	for !p.isAtEnd() {
+		if p.previous().Type == token.Semicolon {
+			return
+		}
+		switch p.peek().Type {
+		case token.Class, token.Fun, token.Var, token.For, token.If, token.While, token.Print, token.Return, token.Break, token.Continue:
+			return
+		}
+		p.advance()
+	}
+}
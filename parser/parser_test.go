@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/ast"
+	"github.com/kriyanshii/interpreter-go/scanner"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// parse parses source and returns its statements as the ast printer
+// prints them, one per line, and the first error reported, as "line N at
+// 'x': message".
+func parse(t *testing.T, source string) (string, string) {
+	t.Helper()
+	tokens := scanner.New(source, func(file string, line, column int, message string) {
+		t.Fatalf("line %d, col %d: %s", line, column, message)
+	}, 0).ScanTokens()
+	var errs []string
+	statements := New(tokens, func(tok token.Token, message string) {
+		errs = append(errs, fmt.Sprintf("line %d at '%s': %s", tok.Line, tok.Lexeme, message))
+	}).Parse()
+	if len(errs) > 0 {
+		return "", errs[0]
+	}
+	var printer ast.Printer
+	printed := make([]string, len(statements))
+	for n, stmt := range statements {
+		printed[n] = printer.PrintStmt(stmt)
+	}
+	return strings.Join(printed, "\n"), ""
+}
+
+func TestInterpolation(t *testing.T) {
+	tests := []struct {
+		name, source, want, err string
+	}{
+		{name: "expressions", source: `print "a${1 + 2}b${x}";`, want: `(print (interpolate a (+ 1.0 2.0) b x))`},
+		{name: "only an expression", source: `print "${x}";`, want: `(print (interpolate x))`},
+		{name: "nested strings", source: `print "a${"b${x}"}";`, want: `(print (interpolate a (interpolate b x)))`},
+		{name: "nested string first", source: `print "${"b"}";`, want: `(print (interpolate b))`},
+		{name: "empty", source: `print "${}";`, err: `line 1 at '}"': Expect expression in interpolation.`},
+		{name: "empty between text", source: `print "a${}b";`, err: `line 1 at '}b"': Expect expression in interpolation.`},
+		{name: "empty after another", source: `print "${x}${}";`, err: `line 1 at '}"': Expect expression in interpolation.`},
+		{name: "empty inside a nested string", source: `print "${"${}"}";`, err: `line 1 at '}"': Expect expression in interpolation.`},
+		{name: "two expressions", source: `print "${x y}";`, err: `line 1 at 'y': Expect '}' after interpolated expression.`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parse(t, test.source)
+			if got != test.want || err != test.err {
+				t.Errorf("got %q and error %q, want %q and error %q", got, err, test.want, test.err)
+			}
+		})
+	}
+}
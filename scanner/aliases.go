@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// Aliases maps words to the keywords they stand for, such as "si" to
+// token.If, so that Lox can be taught in languages other than English.
+// A scanner given aliases scans each one as a token of its keyword's type,
+// whose lexeme is the alias as written, so that errors quote the script's
+// own words. Anything that needs the keyword's usual spelling gets it from
+// the type, with Type.Keyword. The usual spellings keep working.
+type Aliases map[string]token.Type
+
+// NewAliases checks table, which maps each alias to a keyword as written
+// in Lox, such as "si" to "if", and returns it as Aliases. An alias must
+// be a word that could otherwise name a variable.
+func NewAliases(table map[string]string) (Aliases, error) {
+	aliases := make(Aliases, len(table))
+	words := make([]string, 0, len(table))
+	for word := range table {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	for _, word := range words {
+		keyword := token.Lookup(table[word])
+		switch {
+		case keyword == token.Identifier:
+			return nil, fmt.Errorf("%q is not a keyword, so %q can't stand for it", table[word], word)
+		case !isWord(word):
+			return nil, fmt.Errorf("%q can't be used as an alias: it is not a single word", word)
+		case token.Lookup(word) != token.Identifier:
+			return nil, fmt.Errorf("%q can't be used as an alias: it is already a keyword", word)
+		}
+		aliases[word] = keyword
+	}
+	return aliases, nil
+}
+
+// isWord reports whether s would scan as a single identifier.
+func isWord(s string) bool {
+	first, _ := utf8.DecodeRuneInString(s)
+	if s == "" || !isAlpha(first) {
+		return false
+	}
+	for _, c := range s {
+		if !isAlphaNumeric(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetAliases makes s scan the words in aliases as keywords, here and in
+// the files it includes.
+func (s *Scanner) SetAliases(aliases Aliases) {
+	s.aliases = aliases
+}
+
+// Normalize returns source with each alias in it spelled as the keyword it
+// stands for, leaving strings, comments and the files source includes
+// alone. Lexical errors are reported to err as ScanTokens reports them.
+func Normalize(source string, aliases Aliases, err ErrorHandler) string {
+	s := New(source, err, 0)
+	s.aliases = aliases
+	s.skipIncludes = true
+	var b strings.Builder
+	last := 0
+	for _, tok := range s.ScanTokens() {
+		if _, ok := aliases[tok.Lexeme]; !ok {
+			continue
+		}
+		b.WriteString(source[last:tok.Offset])
+		b.WriteString(tok.Type.Keyword())
+		last = tok.Offset + tok.Length
+	}
+	b.WriteString(source[last:])
+	return b.String()
+}
@@ -0,0 +1,803 @@
+// Package scanner turns Lox source text into tokens.
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kriyanshii/interpreter-go/internal/number"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// ErrorHandler is called with each lexical error. file is empty unless a
+// directive attributed the offending line to another file. column is
+// 1-based and counts characters.
+type ErrorHandler func(file string, line, column int, message string)
+
+// Mode is a set of flags controlling how source is scanned.
+type Mode uint
+
+const (
+	// BigInts scans integer literals too large for a float64 to hold
+	// exactly as *big.Int values instead of rounding them.
+	BigInts Mode = 1 << iota
+)
+
+// Scanner turns Lox source text into a flat list of tokens.
+type Scanner struct {
+	err    ErrorHandler
+	mode   Mode
+	source string
+	tokens []token.Token
+
+	start   int
+	current int
+	line    int
+	// file is the name set by the latest //#line directive, if any.
+	file string
+	// interpolations holds, for each string interpolation being scanned,
+	// innermost last, how many braces are open within its expression.
+	interpolations []int
+
+	// path is the file being scanned, against which //#include paths are
+	// resolved. It is empty for source that did not come from a file.
+	path string
+	// includeChain lists the absolute paths of the files that included
+	// this one, outermost first, ending with this file.
+	includeChain []string
+	// included is shared by every scanner working on one program and
+	// records which files have been included already.
+	included map[string]bool
+	// skipIncludes ignores //#include directives, for Normalize.
+	skipIncludes bool
+	// readInclude reads the files //#include directives name, or is nil if
+	// includes are disabled.
+	readInclude IncludeReader
+
+	aliases Aliases
+}
+
+// New returns a scanner for source that reports errors to err.
+func New(source string, err ErrorHandler, mode Mode) *Scanner {
+	return &Scanner{err: err, mode: mode, source: source, line: 1, included: map[string]bool{}, readInclude: readFile}
+}
+
+// IncludeReader reads the file at path for an //#include directive at
+// site, a token with the directive's position. path has already been
+// resolved against the including file.
+type IncludeReader func(site token.Token, path string) ([]byte, error)
+
+func readFile(_ token.Token, path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// SetIncludeReader makes the scanner read included files with read
+// instead of straight from the file system, and reports any //#include
+// directive as an error if read is nil. The reader applies to the files
+// that are included too.
+func (s *Scanner) SetIncludeReader(read IncludeReader) {
+	s.readInclude = read
+}
+
+// NewFile is like New for source read from path, so that //#include
+// directives are resolved relative to it.
+func NewFile(source, path string, err ErrorHandler, mode Mode) *Scanner {
+	s := New(source, err, mode)
+	s.path = path
+	if abs, err := filepath.Abs(path); err == nil {
+		s.includeChain = []string{abs}
+		s.included[abs] = true
+	}
+	return s
+}
+
+// ScanTokens scans the whole source and returns its tokens, always
+// terminated by an EOF token. Lexical errors are reported to the error
+// handler and scanning continues past them.
+func (s *Scanner) ScanTokens() []token.Token {
+	for !s.isAtEnd() {
+		s.start = s.current
+		s.scanToken()
+	}
+	if len(s.interpolations) > 0 {
+		s.start = s.current
+		s.error("Unterminated string interpolation.")
+	}
+	s.tokens = append(s.tokens, token.Token{Type: token.EOF, Line: s.line, File: s.file, Column: s.column(s.current), Offset: s.current})
+	return s.tokens
+}
+
+func (s *Scanner) scanToken() {
+	c := s.advance()
+	switch c {
+	case '(':
+		s.addToken(token.LeftParen)
+	case ')':
+		s.addToken(token.RightParen)
+	case '{':
+		if n := len(s.interpolations); n > 0 {
+			s.interpolations[n-1]++
+		}
+		s.addToken(token.LeftBrace)
+	case '}':
+		n := len(s.interpolations)
+		switch {
+		case n > 0 && s.interpolations[n-1] == 0:
+			// The end of an interpolated expression: back to the string.
+			s.interpolations = s.interpolations[:n-1]
+			s.string()
+		case n > 0:
+			s.interpolations[n-1]--
+			s.addToken(token.RightBrace)
+		default:
+			s.addToken(token.RightBrace)
+		}
+	case ',':
+		s.addToken(token.Comma)
+	case '.':
+		if isDigit(s.peek()) {
+			s.leadingDotNumber()
+		} else {
+			s.addToken(token.Dot)
+		}
+	case '-':
+		if s.match('-') {
+			s.addToken(token.MinusMinus)
+		} else {
+			s.addToken(s.choose('=', token.MinusEqual, token.Minus))
+		}
+	case '+':
+		if s.match('+') {
+			s.addToken(token.PlusPlus)
+		} else {
+			s.addToken(s.choose('=', token.PlusEqual, token.Plus))
+		}
+	case ';':
+		s.addToken(token.Semicolon)
+	case '*':
+		s.addToken(s.choose('=', token.StarEqual, token.Star))
+	case '%':
+		s.addToken(s.choose('=', token.PercentEqual, token.Percent))
+	case '?':
+		s.addToken(token.Question)
+	case ':':
+		s.addToken(token.Colon)
+	case '!':
+		s.addToken(s.choose('=', token.BangEqual, token.Bang))
+	case '=':
+		if s.match('>') {
+			s.addToken(token.Arrow)
+		} else {
+			s.addToken(s.choose('=', token.EqualEqual, token.Equal))
+		}
+	case '<':
+		s.addToken(s.choose('=', token.LessEqual, token.Less))
+	case '>':
+		s.addToken(s.choose('=', token.GreaterEqual, token.Greater))
+	case '/':
+		if s.match('/') {
+			for s.peek() != '\n' && !s.isAtEnd() {
+				s.advance()
+			}
+			comment := s.source[s.start:s.current]
+			switch {
+			case strings.HasPrefix(comment, lineDirective):
+				s.lineDirective(comment[len(lineDirective):])
+			case strings.HasPrefix(comment, includeDirective):
+				s.includeDirective(comment[len(includeDirective):])
+			}
+		} else if s.match('*') {
+			s.blockComment()
+		} else {
+			s.addToken(s.choose('=', token.SlashEqual, token.Slash))
+		}
+	case ' ', '\r', '\t':
+	case '\n':
+		s.line++
+	case '"':
+		if s.peek() == '"' && s.peekNext() == '"' {
+			s.tripleString()
+		} else {
+			s.string()
+		}
+	case '`':
+		s.rawString()
+	default:
+		switch {
+		case isDigit(c):
+			s.number()
+		case isAlpha(c):
+			s.identifier()
+		case c == utf8.RuneError && s.current-s.start == 1:
+			s.error(fmt.Sprintf("Invalid UTF-8 byte 0x%02X.", s.source[s.start]))
+		default:
+			s.error(fmt.Sprintf("Unexpected character: %c", c))
+		}
+	}
+}
+
+// blockComment skips a /* ... */ comment, whose opening has been consumed.
+// Block comments nest, so that commenting out code that already holds one
+// works.
+func (s *Scanner) blockComment() {
+	depth := 1
+	for depth > 0 && !s.isAtEnd() {
+		switch c := s.advance(); {
+		case c == '\n':
+			s.line++
+		case c == '/' && s.match('*'):
+			depth++
+		case c == '*' && s.match('/'):
+			depth--
+		}
+	}
+	if depth > 0 {
+		s.error("Unterminated block comment.")
+	}
+}
+
+// lineDirective is the comment prefix that remaps source positions.
+const lineDirective = "//#line "
+
+// lineDirective applies a `//#line N "file"` comment: the line after it is
+// reported as line N of file. The file name is optional and defaults to
+// the current one. Code generators use this so that diagnostics point at
+// the source they were generated from.
+func (s *Scanner) lineDirective(args string) {
+	number, file, hasFile := strings.Cut(strings.TrimSpace(args), " ")
+	line, err := strconv.Atoi(number)
+	if err != nil || line < 1 {
+		s.error("Malformed #line directive: expected a positive line number.")
+		return
+	}
+	if hasFile {
+		name, err := strconv.Unquote(strings.TrimSpace(file))
+		if err != nil {
+			s.error("Malformed #line directive: expected a quoted file name.")
+			return
+		}
+		s.file = name
+	}
+	// The newline ending the directive advances to the requested line.
+	s.line = line - 1
+}
+
+// includeDirective is the comment prefix that splices in another file.
+const includeDirective = "//#include "
+
+// includeDirective applies an `//#include "file.lox"` comment by scanning
+// the named file in place of the comment. Paths are relative to the file
+// holding the directive. Each file is included at most once per program,
+// and a file that ends up including itself is reported as a cycle. Tokens
+// from the included file carry its name and lines, so errors point there.
+func (s *Scanner) includeDirective(args string) {
+	if s.skipIncludes {
+		return
+	}
+	name, err := strconv.Unquote(strings.TrimSpace(args))
+	if err != nil || name == "" {
+		s.error("Malformed #include directive: expected a quoted file name.")
+		return
+	}
+	if s.readInclude == nil {
+		s.error("Includes are disabled.")
+		return
+	}
+	path := name
+	if !filepath.IsAbs(path) && s.path != "" {
+		path = filepath.Join(filepath.Dir(s.path), path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		s.error(fmt.Sprintf("Cannot include '%s': %v.", name, err))
+		return
+	}
+	for i, outer := range s.includeChain {
+		if outer == abs {
+			cycle := make([]string, 0, len(s.includeChain)-i+1)
+			for _, p := range s.includeChain[i:] {
+				cycle = append(cycle, filepath.Base(p))
+			}
+			cycle = append(cycle, filepath.Base(abs))
+			s.error("Include cycle: " + strings.Join(cycle, " -> ") + ".")
+			return
+		}
+	}
+	if s.included[abs] {
+		return
+	}
+	s.included[abs] = true
+
+	site := token.Token{Line: s.line, File: s.file, Column: s.column(s.start), Offset: s.start}
+	source, err := s.readInclude(site, path)
+	if err != nil {
+		s.error(fmt.Sprintf("Cannot include '%s': %v.", name, err))
+		return
+	}
+	inner := &Scanner{
+		err:          s.err,
+		mode:         s.mode,
+		source:       string(source),
+		line:         1,
+		file:         path,
+		path:         path,
+		includeChain: append(s.includeChain[:len(s.includeChain):len(s.includeChain)], abs),
+		included:     s.included,
+		aliases:      s.aliases,
+		readInclude:  s.readInclude,
+	}
+	tokens := inner.ScanTokens()
+	s.tokens = append(s.tokens, tokens[:len(tokens)-1]...)
+}
+
+func (s *Scanner) identifier() {
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+	text := s.source[s.start:s.current]
+	if keyword, ok := s.aliases[text]; ok {
+		s.addToken(keyword)
+		return
+	}
+	s.addToken(token.Lookup(text))
+}
+
+// number scans a number literal: decimal digits with an optional
+// fraction and exponent, as in 12, 1.5 and 6.02e23, or a 0x, 0o or 0b
+// integer. Digits may be grouped with '_', as in 1_000_000.
+func (s *Scanner) number() {
+	if s.source[s.start] == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			s.radixNumber(16, "hexadecimal")
+			return
+		case 'o', 'O':
+			s.radixNumber(8, "octal")
+			return
+		case 'b', 'B':
+			s.radixNumber(2, "binary")
+			return
+		}
+	}
+
+	s.digits()
+	if s.peek() == '.' && isDigit(s.peekNext()) {
+		s.advance()
+		s.digits()
+	}
+	// A '.' not followed by a digit is left alone when a property name
+	// follows, as in 123.sqrt(), but otherwise it is a mistake.
+	if s.peek() == '.' && !isAlpha(s.peekPast(s.current+1)) {
+		text := s.source[s.start:s.current]
+		s.error(fmt.Sprintf("A number can't end with '.'; write '%s.0' or '%s'.", text, text))
+		s.advance()
+	}
+	if s.exponentFollows() {
+		s.advance()
+		if c := s.peek(); c == '+' || c == '-' {
+			s.advance()
+		}
+		s.digits()
+	}
+
+	text := strings.TrimSuffix(s.source[s.start:s.current], ".")
+	for i := 0; i < len(text); i++ {
+		if text[i] == '_' && (i+1 == len(text) || !isDigit(rune(text[i+1]))) {
+			s.badNumber(fmt.Sprintf("Digit separators in '%s' must sit between digits.", text))
+			return
+		}
+	}
+	text = strings.ReplaceAll(text, "_", "")
+	if s.mode&BigInts != 0 {
+		if n := parseBigLiteral(text, 10); n != nil {
+			s.addTokenLiteral(token.Number, n)
+			return
+		}
+	}
+	value, err := number.ParseDecimal(text)
+	if errors.Is(err, strconv.ErrRange) {
+		s.badNumber(fmt.Sprintf("The decimal literal '%s' is too large.", s.source[s.start:s.current]))
+		return
+	}
+	if err != nil {
+		s.badNumber("Invalid number literal '" + text + "'.")
+		return
+	}
+	s.addTokenLiteral(token.Number, value)
+}
+
+// leadingDotNumber scans a number written without its leading zero, such
+// as .5, whose '.' has been consumed. It is reported, but produces the
+// number that was meant so that the parser doesn't report more errors.
+func (s *Scanner) leadingDotNumber() {
+	s.digits()
+	text := strings.ReplaceAll(s.source[s.start:s.current], "_", "")
+	s.error(fmt.Sprintf("A number can't start with '.'; write '0%s'.", text))
+	value, _ := number.ParseDecimal("0" + text)
+	s.addTokenLiteral(token.Number, value)
+}
+
+// digits scans a run of decimal digits, along with any '_' separating
+// them.
+func (s *Scanner) digits() {
+	for isDigit(s.peek()) || s.peek() == '_' {
+		s.advance()
+	}
+}
+
+// exponentFollows reports whether the number being scanned continues with
+// an exponent: 'e' or 'E', an optional sign, then a digit. Otherwise the
+// 'e' is left to start an identifier.
+func (s *Scanner) exponentFollows() bool {
+	rest := s.source[s.current:]
+	if rest == "" || rest[0] != 'e' && rest[0] != 'E' {
+		return false
+	}
+	rest = rest[1:]
+	if rest != "" && (rest[0] == '+' || rest[0] == '-') {
+		rest = rest[1:]
+	}
+	return rest != "" && isDigit(rune(rest[0]))
+}
+
+// radixNumber scans the digits of a 0x, 0o or 0b literal. The prefix
+// letter has not been consumed yet. Every alphanumeric character after the
+// prefix is treated as part of the literal so that "0b102" is reported as
+// one bad literal rather than a number followed by another number. As in
+// decimal literals, '_' may separate digits.
+func (s *Scanner) radixNumber(base int, name string) {
+	s.advance()
+	digitsStart := s.current
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+	digits := s.source[digitsStart:s.current]
+	if digits == "" {
+		s.badNumber(fmt.Sprintf("Expected %s digits after '%s'.", name, s.source[s.start:s.current]))
+		return
+	}
+	if strings.HasPrefix(digits, "_") || strings.HasSuffix(digits, "_") || strings.Contains(digits, "__") {
+		s.badNumber(fmt.Sprintf("Digit separators in '%s' must sit between digits.", s.source[s.start:s.current]))
+		return
+	}
+	digits = strings.ReplaceAll(digits, "_", "")
+	for _, d := range digits {
+		if digitValue(d) >= base {
+			s.badNumber(fmt.Sprintf("Invalid digit '%c' in %s literal.", d, name))
+			return
+		}
+	}
+
+	if s.mode&BigInts != 0 {
+		if n := parseBigLiteral(digits, base); n != nil {
+			s.addTokenLiteral(token.Number, n)
+			return
+		}
+	}
+	value, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		s.badNumber(fmt.Sprintf("The %s literal '%s' is too large.", name, s.source[s.start:s.current]))
+		return
+	}
+	s.addTokenLiteral(token.Number, float64(value))
+}
+
+// badNumber reports message about the number being scanned. Like
+// leadingDotNumber, it still produces a Number token, so that the parser
+// doesn't go on to report a missing expression.
+func (s *Scanner) badNumber(message string) {
+	s.error(message)
+	s.addTokenLiteral(token.Number, 0.0)
+}
+
+// string scans a "-delimited string, decoding its escape sequences: \n,
+// \t, \r, \", \\, \$ and \u{XXXX}, which stands for the Unicode code
+// point with the hexadecimal number XXXX. A bad escape is reported and
+// kept in the literal as written.
+//
+// A "${" starts an interpolated expression. The text so far becomes an
+// Interpolation token, the expression is scanned as ordinary tokens, and
+// the "}" that closes it calls string again to scan the rest of the
+// literal, up to the next "${" or the closing quote.
+func (s *Scanner) string() {
+	var value strings.Builder
+	for s.peek() != '"' && !s.isAtEnd() {
+		from := s.current
+		switch s.advance() {
+		case '\n':
+			s.line++
+		case '\\':
+			if s.isAtEnd() {
+				continue
+			}
+			if decoded, ok := s.escape(); ok {
+				value.WriteRune(decoded)
+				continue
+			}
+		case '$':
+			if s.match('{') {
+				s.addTokenLiteral(token.Interpolation, value.String())
+				s.interpolations = append(s.interpolations, 0)
+				return
+			}
+		}
+		value.WriteString(s.source[from:s.current])
+	}
+	if s.isAtEnd() {
+		s.error("Unterminated string.")
+		return
+	}
+
+	// The closing quote.
+	s.advance()
+	s.addTokenLiteral(token.String, value.String())
+}
+
+// escapes maps the letter after a backslash to the character it stands
+// for.
+var escapes = map[rune]rune{'n': '\n', 't': '\t', 'r': '\r', '"': '"', '\\': '\\', '$': '$'}
+
+// escape decodes the escape sequence after a backslash. If it is invalid,
+// escape reports it and consumes nothing, leaving the caller to keep the
+// backslash as written.
+func (s *Scanner) escape() (rune, bool) {
+	at := s.current - 1
+	c := s.peek()
+	if decoded, ok := escapes[c]; ok {
+		s.advance()
+		return decoded, true
+	}
+	if c != 'u' {
+		if c == '\n' {
+			s.errorAt(at, "Invalid escape sequence: a backslash can't end a line.")
+		} else {
+			s.errorAt(at, fmt.Sprintf("Invalid escape sequence '\\%c'.", c))
+		}
+		return 0, false
+	}
+
+	rest := s.source[s.current+1:]
+	digits, _, closed := strings.Cut(rest, "}")
+	if !strings.HasPrefix(rest, "{") || !closed || len(digits) < 2 || len(digits) > 7 {
+		s.errorAt(at, "Invalid Unicode escape: expected \\u{XXXX} with 1 to 6 hexadecimal digits.")
+		return 0, false
+	}
+	code, err := strconv.ParseUint(digits[1:], 16, 32)
+	if err != nil {
+		s.errorAt(at, "Invalid Unicode escape: expected \\u{XXXX} with 1 to 6 hexadecimal digits.")
+		return 0, false
+	}
+	if !utf8.ValidRune(rune(code)) {
+		s.errorAt(at, fmt.Sprintf("Invalid Unicode escape: U+%X is not a valid code point.", code))
+		return 0, false
+	}
+	s.current += 1 + len(digits) + 1
+	return rune(code), true
+}
+
+// tripleString scans a """-delimited string, which may span lines and
+// contain lone double quotes. When the opening delimiter ends its line the
+// literal is treated as a text block and normalized by dedentTextBlock;
+// otherwise the contents are kept verbatim.
+func (s *Scanner) tripleString() {
+	s.advance()
+	s.advance()
+	for !s.isAtEnd() && !strings.HasPrefix(s.source[s.current:], `"""`) {
+		if s.peek() == '\n' {
+			s.line++
+		}
+		s.advance()
+	}
+	if s.isAtEnd() {
+		s.error("Unterminated triple-quoted string.")
+		return
+	}
+
+	s.current += 3
+	body := s.source[s.start+3 : s.current-3]
+	if first, rest, ok := strings.Cut(body, "\n"); ok && strings.TrimSpace(first) == "" {
+		body = dedentTextBlock(rest)
+	}
+	s.addTokenLiteral(token.String, body)
+}
+
+// dedentTextBlock normalizes the body of a text block: line endings become
+// "\n", a final whitespace-only line (the one holding the closing
+// delimiter) is dropped, and the indentation shared by every non-blank line
+// is removed.
+func dedentTextBlock(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	if strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent, found := "", false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			indent, found = lead, true
+			continue
+		}
+		for !strings.HasPrefix(lead, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		} else {
+			lines[i] = line[len(indent):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rawString scans a backtick-delimited string. Nothing inside it is
+// interpreted: backslashes and newlines end up in the literal verbatim.
+func (s *Scanner) rawString() {
+	for s.peek() != '`' && !s.isAtEnd() {
+		if s.peek() == '\n' {
+			s.line++
+		}
+		s.advance()
+	}
+	if s.isAtEnd() {
+		s.error("Unterminated raw string.")
+		return
+	}
+
+	s.advance()
+	s.addTokenLiteral(token.String, s.source[s.start+1:s.current-1])
+}
+
+func (s *Scanner) choose(expected rune, matched, unmatched token.Type) token.Type {
+	if s.match(expected) {
+		return matched
+	}
+	return unmatched
+}
+
+// The source is read a rune at a time, so that multi-byte characters are
+// never split. A byte that isn't valid UTF-8 reads as utf8.RuneError.
+
+func (s *Scanner) match(expected rune) bool {
+	if s.isAtEnd() || s.peek() != expected {
+		return false
+	}
+	s.advance()
+	return true
+}
+
+func (s *Scanner) peek() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	c, _ := utf8.DecodeRuneInString(s.source[s.current:])
+	return c
+}
+
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if s.current+size >= len(s.source) {
+		return 0
+	}
+	c, _ := utf8.DecodeRuneInString(s.source[s.current+size:])
+	return c
+}
+
+// peekPast returns the first character at or after offset that isn't
+// whitespace, or 0 if there is none.
+func (s *Scanner) peekPast(offset int) rune {
+	c, _ := utf8.DecodeRuneInString(strings.TrimLeftFunc(s.source[offset:], unicode.IsSpace))
+	if c == utf8.RuneError {
+		return 0
+	}
+	return c
+}
+
+func (s *Scanner) advance() rune {
+	c, size := utf8.DecodeRuneInString(s.source[s.current:])
+	s.current += size
+	return c
+}
+
+func (s *Scanner) isAtEnd() bool {
+	return s.current >= len(s.source)
+}
+
+func (s *Scanner) addToken(tokenType token.Type) {
+	s.addTokenLiteral(tokenType, nil)
+}
+
+func (s *Scanner) addTokenLiteral(tokenType token.Type, literal any) {
+	text := s.source[s.start:s.current]
+	s.tokens = append(s.tokens, token.Token{
+		Type: tokenType, Lexeme: text, Literal: literal, Line: s.line, File: s.file,
+		Column: s.column(s.start), Offset: s.start, Length: len(text),
+	})
+}
+
+// error reports a lexical error in the text scanned since s.start. It is
+// located at the start of that text, or where scanning stopped if the text
+// spans lines, since the error is reported on the current line.
+func (s *Scanner) error(message string) {
+	at := s.start
+	if strings.Contains(s.source[s.start:s.current], "\n") {
+		at = s.current
+	}
+	s.errorAt(at, message)
+}
+
+// errorAt reports a lexical error at the byte at offset, which must be on
+// the current line.
+func (s *Scanner) errorAt(offset int, message string) {
+	s.err(s.file, s.line, s.column(offset), message)
+}
+
+// column returns the 1-based column of the byte at offset.
+func (s *Scanner) column(offset int) int {
+	lineStart := strings.LastIndexByte(s.source[:offset], '\n') + 1
+	return utf8.RuneCountInString(s.source[lineStart:offset]) + 1
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isAlpha reports whether c can start an identifier: an underscore or a
+// letter in any script.
+func isAlpha(c rune) bool {
+	if c < utf8.RuneSelf {
+		return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+	}
+	return unicode.IsLetter(c)
+}
+
+// isAlphaNumeric reports whether c can continue an identifier. Besides
+// letters and digits this takes in combining marks, which scripts such as
+// Devanagari need to spell most words.
+func isAlphaNumeric(c rune) bool {
+	if c < utf8.RuneSelf {
+		return isAlpha(c) || isDigit(c)
+	}
+	return unicode.In(c, unicode.Letter, unicode.Nd, unicode.Mn, unicode.Mc)
+}
+
+// digitValue returns the numeric value of an alphanumeric digit in bases up
+// to 36, or 36 for anything else.
+func digitValue(c rune) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	default:
+		return 36
+	}
+}
+
+// parseBigLiteral parses the digits of an integer literal too large for a
+// float64 to hold exactly. It returns nil if the digits fit.
+func parseBigLiteral(digits string, base int) *big.Int {
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok || n.CmpAbs(big.NewInt(number.MaxExactInt)) <= 0 {
+		return nil
+	}
+	return n
+}
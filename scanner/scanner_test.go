@@ -0,0 +1,530 @@
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kriyanshii/interpreter-go/internal/number"
+	"github.com/kriyanshii/interpreter-go/token"
+)
+
+// TestNumberRoundTrip checks that every number print can produce scans
+// back to the identical float64.
+func TestNumberRoundTrip(t *testing.T) {
+	values := []float64{0, 1, 0.1, 1.0 / 3, math.Pi, 5e-324, math.SmallestNonzeroFloat64, math.MaxFloat64, 1 << 53, 1<<53 + 2}
+	r := rand.New(rand.NewSource(1))
+	for len(values) < 10000 {
+		v := math.Abs(math.Float64frombits(r.Uint64()))
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			values = append(values, v)
+		}
+	}
+
+	for _, v := range values {
+		text := number.Format(v)
+		failed := false
+		tokens := New(text, func(string, int, int, string) { failed = true }, 0).ScanTokens()
+		if failed || len(tokens) != 2 || tokens[0].Type != token.Number {
+			t.Fatalf("scanning %q (from %v) gave %v", text, v, tokens)
+		}
+		if got := tokens[0].Literal.(float64); math.Float64bits(got) != math.Float64bits(v) {
+			t.Fatalf("%v printed as %q scans back as %v", v, text, got)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	source := "var x = 1;\n  print \"héllo\" + x;"
+	tokens := New(source, func(file string, line, column int, message string) {
+		t.Fatalf("unexpected error at %d:%d: %s", line, column, message)
+	}, 0).ScanTokens()
+
+	want := []struct {
+		lexeme               string
+		line, column, offset int
+	}{
+		{"var", 1, 1, 0}, {"x", 1, 5, 4}, {"=", 1, 7, 6}, {"1", 1, 9, 8}, {";", 1, 10, 9},
+		{"print", 2, 3, 13}, {`"héllo"`, 2, 9, 19}, {"+", 2, 17, 28}, {"x", 2, 19, 30}, {";", 2, 20, 31},
+		{"", 2, 21, 32},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(tokens), len(want))
+	}
+	for i, w := range want {
+		tok := tokens[i]
+		if tok.Lexeme != w.lexeme || tok.Line != w.line || tok.Column != w.column || tok.Offset != w.offset || tok.Length != len(w.lexeme) {
+			t.Errorf("token %d = %q at %d:%d offset %d length %d, want %q at %d:%d offset %d length %d",
+				i, tok.Lexeme, tok.Line, tok.Column, tok.Offset, tok.Length, w.lexeme, w.line, w.column, w.offset, len(w.lexeme))
+		}
+	}
+}
+
+func TestErrorColumn(t *testing.T) {
+	var got []int
+	New("x = @;\n\"open\nstring", func(file string, line, column int, message string) {
+		got = append(got, line, column)
+	}, 0).ScanTokens()
+	if want := []int{1, 5, 3, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("errors at %v, want %v", got, want)
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	var messages []string
+	tokens := New("// ünïcödé 🎉\nprint \"日本語\"; € \xff", func(file string, line, column int, message string) {
+		messages = append(messages, message)
+	}, 0).ScanTokens()
+
+	if len(tokens) != 4 || tokens[1].Literal != "日本語" || tokens[2].Column != 12 {
+		t.Errorf("got tokens %v", tokens)
+	}
+	want := []string{"Unexpected character: €", "Invalid UTF-8 byte 0xFF."}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("got errors %q, want %q", messages, want)
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	source := "var café = 1; fun नमस्ते(名前) {} _x9 ünd"
+	tokens := New(source, func(file string, line, column int, message string) {
+		t.Fatalf("unexpected error at %d:%d: %s", line, column, message)
+	}, 0).ScanTokens()
+
+	var identifiers []string
+	for _, tok := range tokens {
+		if tok.Type == token.Identifier {
+			identifiers = append(identifiers, tok.Lexeme)
+		}
+	}
+	if want := []string{"café", "नमस्ते", "名前", "_x9", "ünd"}; !reflect.DeepEqual(identifiers, want) {
+		t.Errorf("got identifiers %q, want %q", identifiers, want)
+	}
+}
+
+func TestEscapes(t *testing.T) {
+	tests := []struct {
+		source, value, err string
+	}{
+		{`"a\tb\nc\r\"d\"\\"`, "a\tb\nc\r\"d\"\\", ""},
+		{`"\u{48}\u{e9}\u{1F600}"`, "Hé😀", ""},
+		{`"bad \q"`, `bad \q`, `Invalid escape sequence '\q'.`},
+		{`"\u{110000}"`, `\u{110000}`, "Invalid Unicode escape: U+110000 is not a valid code point."},
+		{`"\u{}"`, `\u{}`, `Invalid Unicode escape: expected \u{XXXX} with 1 to 6 hexadecimal digits.`},
+		{`"\u12"`, `\u12`, `Invalid Unicode escape: expected \u{XXXX} with 1 to 6 hexadecimal digits.`},
+	}
+	for _, test := range tests {
+		var err string
+		tokens := New(test.source, func(file string, line, column int, message string) {
+			err = message
+		}, 0).ScanTokens()
+		if err != test.err {
+			t.Errorf("%s: got error %q, want %q", test.source, err, test.err)
+		}
+		if tokens[0].Literal != test.value {
+			t.Errorf("%s: got %q, want %q", test.source, tokens[0].Literal, test.value)
+		}
+	}
+}
+
+func TestRawStrings(t *testing.T) {
+	tests := []struct {
+		source, value, err string
+	}{
+		{"`a\\n${b}`", `a\n${b}`, ""},
+		{"`two\nlines`", "two\nlines", ""},
+		{"``", "", ""},
+		{"`open", "", "Unterminated raw string."},
+	}
+	for _, test := range tests {
+		var err string
+		tokens := New(test.source, func(file string, line, column int, message string) {
+			err = message
+		}, 0).ScanTokens()
+		if err != test.err {
+			t.Errorf("%q: got error %q, want %q", test.source, err, test.err)
+		}
+		if tokens[0].Literal != nil && tokens[0].Literal != test.value {
+			t.Errorf("%q: got %q, want %q", test.source, tokens[0].Literal, test.value)
+		}
+	}
+}
+
+func TestTextBlocks(t *testing.T) {
+	tests := []struct {
+		name, source, value string
+	}{
+		{"on one line", `"""a "quoted" word"""`, `a "quoted" word`},
+		{"text after the opening delimiter", "\"\"\"a\n  b\"\"\"", "a\n  b"},
+		{"closing delimiter on its own line", "\"\"\"\n    a\n      b\n    \"\"\"", "a\n  b"},
+		{"closing delimiter after the text", "\"\"\"\n    a\n    b\"\"\"", "a\nb"},
+		{"closing delimiter indented less", "\"\"\"\n    a\n  \"\"\"", "a"},
+		{"blank lines", "\"\"\"\n    a\n\n  \n    b\n    \"\"\"", "a\n\n\nb"},
+		{"tabs", "\"\"\"\n\t\ta\n\tb\n\"\"\"", "\ta\nb"},
+		{"tabs and spaces sharing a prefix", "\"\"\"\n\t  a\n\t b\n\"\"\"", " a\nb"},
+		{"tabs and spaces differing", "\"\"\"\n    a\n\tb\n\"\"\"", "    a\n\tb"},
+		{"CRLF line endings", "\"\"\"\r\n  a\r\n  b\r\n  \"\"\"", "a\nb"},
+	}
+	for _, test := range tests {
+		tokens := New(test.source, func(file string, line, column int, message string) {
+			t.Errorf("%s: unexpected error: %s", test.name, message)
+		}, 0).ScanTokens()
+		if tokens[0].Type != token.String || tokens[0].Literal != test.value {
+			t.Errorf("%s: got %s %q, want %q", test.name, tokens[0].Type, tokens[0].Literal, test.value)
+		}
+		if want := strings.Count(test.source, "\n") + 1; tokens[1].Line != want {
+			t.Errorf("%s: got the next token on line %d, want %d", test.name, tokens[1].Line, want)
+		}
+	}
+
+	var err string
+	New("\"\"\"\n  open\"\"", func(file string, line, column int, message string) {
+		err = message
+	}, 0).ScanTokens()
+	if err != "Unterminated triple-quoted string." {
+		t.Errorf("got error %q for an unterminated text block", err)
+	}
+}
+
+func TestBlockComments(t *testing.T) {
+	var errors []int
+	tokens := New("a /* one\n/* two */\n*/ b /**/ c /* open\n/* */\n", func(file string, line, column int, message string) {
+		if message != "Unterminated block comment." {
+			t.Errorf("unexpected error: %s", message)
+		}
+		errors = append(errors, line)
+	}, 0).ScanTokens()
+
+	var got []string
+	for _, tok := range tokens {
+		got = append(got, fmt.Sprintf("%s@%d", tok.Lexeme, tok.Line))
+	}
+	if want := []string{"a@1", "b@3", "c@3", "@5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got tokens %v, want %v", got, want)
+	}
+	if want := []int{5}; !reflect.DeepEqual(errors, want) {
+		t.Errorf("got errors on lines %v, want %v", errors, want)
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	tokens := New(`"a ${b + "c${d}"} e ${{}}"`, func(file string, line, column int, message string) {
+		t.Errorf("unexpected error at %d:%d: %s", line, column, message)
+	}, 0).ScanTokens()
+
+	var got []string
+	for _, tok := range tokens {
+		got = append(got, fmt.Sprintf("%s %s", tok.Type, tok.Lexeme))
+	}
+	want := []string{
+		`INTERPOLATION "a ${`, "IDENTIFIER b", "PLUS +", `INTERPOLATION "c${`, "IDENTIFIER d", `STRING }"`,
+		`INTERPOLATION } e ${`, "LEFT_BRACE {", "RIGHT_BRACE }", `STRING }"`, "EOF ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q,\nwant %q", got, want)
+	}
+	if tokens[0].Literal != "a " || tokens[6].Literal != " e " {
+		t.Errorf("got literals %q and %q", tokens[0].Literal, tokens[6].Literal)
+	}
+}
+
+func TestNumberFormats(t *testing.T) {
+	for source, want := range map[string]float64{
+		"1_000_000": 1e6, "1.5e3": 1500, "2E-2": 0.02, "1e+2": 100, "3_0.2_5": 30.25,
+		"1_0e1_0": 1e11, "0xFF": 255, "0xdead_beef": 0xdeadbeef, "0b1010": 10, "0b1111_0000": 240, "0o7_5_5": 0o755,
+	} {
+		tokens := New(source, func(file string, line, column int, message string) {
+			t.Errorf("scanning %q: unexpected error: %s", source, message)
+		}, 0).ScanTokens()
+		if len(tokens) != 2 || tokens[0].Literal != want || tokens[0].Lexeme != source {
+			t.Errorf("scanning %q gave %v, want the number %v", source, tokens, want)
+		}
+	}
+
+	// A bad literal is reported once and still scans as a number, so that
+	// the parser doesn't report a missing expression after it.
+	for _, source := range []string{"1__0", "1_", "1_.5", "1.5_e3", "0x_FF", "0xFF_", "0b1__0", "1e400", "0x", "0b102", "0o9"} {
+		errors := 0
+		tokens := New(source, func(string, int, int, string) { errors++ }, 0).ScanTokens()
+		if errors != 1 {
+			t.Errorf("scanning %q reported %d errors, want 1", source, errors)
+		}
+		if len(tokens) != 2 || tokens[0].Type != token.Number || tokens[0].Lexeme != source {
+			t.Errorf("scanning %q gave %v, want a single number", source, tokens)
+		}
+	}
+}
+
+func TestNumberDots(t *testing.T) {
+	var got []string
+	tokens := New("123.sqrt() 1.5.abs .5 7. ; 8.\n  sqrt", func(file string, line, column int, message string) {
+		got = append(got, fmt.Sprintf("%d:%d: %s", line, column, message))
+	}, 0).ScanTokens()
+	want := []string{
+		"1:20: A number can't start with '.'; write '0.5'.",
+		"1:23: A number can't end with '.'; write '7.0' or '7'.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got errors %q,\nwant %q", got, want)
+	}
+
+	var types []token.Type
+	for _, tok := range tokens {
+		types = append(types, tok.Type)
+	}
+	wantTypes := []token.Type{
+		token.Number, token.Dot, token.Identifier, token.LeftParen, token.RightParen,
+		token.Number, token.Dot, token.Identifier, token.Number, token.Number,
+		token.Semicolon, token.Number, token.Dot, token.Identifier, token.EOF,
+	}
+	if !reflect.DeepEqual(types, wantTypes) {
+		t.Errorf("got %v,\nwant %v", types, wantTypes)
+	}
+	if tokens[8].Literal != 0.5 || tokens[9].Literal != 7.0 || tokens[9].Lexeme != "7." {
+		t.Errorf("got %v and %v", tokens[8], tokens[9])
+	}
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	tokens := New("+= -= *= /= %= + - * / % //=", func(file string, line, column int, message string) {
+		t.Errorf("unexpected error at %d:%d: %s", line, column, message)
+	}, 0).ScanTokens()
+
+	var got []token.Type
+	for _, tok := range tokens {
+		got = append(got, tok.Type)
+	}
+	want := []token.Type{
+		token.PlusEqual, token.MinusEqual, token.StarEqual, token.SlashEqual, token.PercentEqual,
+		token.Plus, token.Minus, token.Star, token.Slash, token.Percent, token.EOF,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v,\nwant %v", got, want)
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	tokens := New("++ -- +++ --= a--b", func(file string, line, column int, message string) {
+		t.Errorf("unexpected error at %d:%d: %s", line, column, message)
+	}, 0).ScanTokens()
+
+	var got []token.Type
+	for _, tok := range tokens {
+		got = append(got, tok.Type)
+	}
+	want := []token.Type{
+		token.PlusPlus, token.MinusMinus, token.PlusPlus, token.Plus, token.MinusMinus, token.Equal,
+		token.Identifier, token.MinusMinus, token.Identifier, token.EOF,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v,\nwant %v", got, want)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	aliases, err := NewAliases(map[string]string{"si": "if", "mientras": "while", "imprimir": "print"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := `si (x) mientras (y) imprimir "si"; // si
+if siempre;`
+	fail := func(file string, line, column int, message string) {
+		t.Errorf("unexpected error at %d:%d: %s", line, column, message)
+	}
+	s := New(source, fail, 0)
+	s.SetAliases(aliases)
+	tokens := s.ScanTokens()
+
+	type scanned struct {
+		Type   token.Type
+		Lexeme string
+		Length int
+	}
+	var got []scanned
+	for _, tok := range tokens {
+		if tok.Lexeme != source[tok.Offset:tok.Offset+tok.Length] {
+			t.Errorf("%v: the lexeme is not the source text it came from", tok)
+		}
+		if tok.Type != token.LeftParen && tok.Type != token.RightParen {
+			got = append(got, scanned{tok.Type, tok.Lexeme, tok.Length})
+		}
+	}
+	want := []scanned{
+		{token.If, "si", 2}, {token.Identifier, "x", 1},
+		{token.While, "mientras", 8}, {token.Identifier, "y", 1},
+		{token.Print, "imprimir", 8}, {token.String, `"si"`, 4}, {token.Semicolon, ";", 1},
+		{token.If, "if", 2}, {token.Identifier, "siempre", 7}, {token.Semicolon, ";", 1},
+		{token.EOF, "", 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v,\nwant %v", got, want)
+	}
+
+	normalized := Normalize(source, aliases, fail)
+	if want := `if (x) while (y) print "si"; // si
+if siempre;`; normalized != want {
+		t.Errorf("Normalize: got %q, want %q", normalized, want)
+	}
+}
+
+func TestBadAliases(t *testing.T) {
+	for _, table := range []map[string]string{
+		{"si": "iff"},
+		{"while": "if"},
+		{"dos palabras": "if"},
+		{"1si": "if"},
+	} {
+		if _, err := NewAliases(table); err == nil {
+			t.Errorf("NewAliases(%v) succeeded", table)
+		}
+	}
+}
+
+func TestArrow(t *testing.T) {
+	tokens := New("=> == = >= =>=", func(file string, line, column int, message string) {
+		t.Errorf("unexpected error at %d:%d: %s", line, column, message)
+	}, 0).ScanTokens()
+
+	var got []token.Type
+	for _, tok := range tokens {
+		got = append(got, tok.Type)
+	}
+	want := []token.Type{token.Arrow, token.EqualEqual, token.Equal, token.GreaterEqual, token.Arrow, token.Equal, token.EOF}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v,\nwant %v", got, want)
+	}
+}
+
+func TestLineDirectives(t *testing.T) {
+	tests := []struct {
+		name, source string
+		want         []string
+		err          string
+	}{
+		{"line and file", "a\n//#line 30 \"gen.lox\"\nb\nc", []string{"a@:1", "b@gen.lox:30", "c@gen.lox:31", "@gen.lox:31"}, ""},
+		{"line only keeps the file", "//#line 5 \"x.lox\"\na\n//#line 40\nb", []string{"a@x.lox:5", "b@x.lox:40", "@x.lox:40"}, ""},
+		{"line only at first", "//#line 9\na", []string{"a@:9", "@:9"}, ""},
+		{"not a number", "//#line x\na", []string{"a@:2", "@:2"}, "1: Malformed #line directive: expected a positive line number."},
+		{"zero", "//#line 0\na", []string{"a@:2", "@:2"}, "1: Malformed #line directive: expected a positive line number."},
+		{"unquoted file", "//#line 3 gen.lox\na", []string{"a@:2", "@:2"}, "1: Malformed #line directive: expected a quoted file name."},
+		{"errors use the new position", "//#line 7 \"gen.lox\"\n@", []string{"@gen.lox:7"}, "gen.lox:7: Unexpected character: @"},
+	}
+	for _, test := range tests {
+		var err string
+		tokens := New(test.source, func(file string, line, column int, message string) {
+			if err == "" {
+				err = fmt.Sprintf("%s%d: %s", prefix(file), line, message)
+			}
+		}, 0).ScanTokens()
+		var got []string
+		for _, tok := range tokens {
+			got = append(got, fmt.Sprintf("%s@%s:%d", tok.Lexeme, tok.File, tok.Line))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got tokens %q, want %q", test.name, got, test.want)
+		}
+		if err != test.err {
+			t.Errorf("%s: got error %q, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+// prefix returns file as error messages start with it: followed by a
+// colon, or empty for the source being scanned.
+func prefix(file string) string {
+	if file == "" {
+		return ""
+	}
+	return file + ":"
+}
+
+func TestIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.lox":    "//#include \"lib/a.lox\"\n//#include \"lib/b.lox\"\nmain",
+		"lib/a.lox":   "//#include \"b.lox\"\na",
+		"lib/b.lox":   "b",
+		"cycle1.lox":  "//#include \"cycle2.lox\"\none",
+		"cycle2.lox":  "//#include \"cycle1.lox\"\ntwo",
+		"self.lox":    "//#include \"self.lox\"\nself",
+		"missing.lox": "//#include \"nowhere.lox\"\nx",
+		"bad.lox":     "//#include nowhere.lox\nx",
+	}
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lib := filepath.Join(dir, "lib")
+	tests := []struct {
+		file string
+		want []string
+		err  string
+	}{
+		// b.lox is included by a.lox first, and only then.
+		{"main.lox", []string{"b@" + filepath.Join(lib, "b.lox") + ":1", "a@" + filepath.Join(lib, "a.lox") + ":2", "main@:3", "@:3"}, ""},
+		{"cycle1.lox", []string{"two@" + filepath.Join(dir, "cycle2.lox") + ":2", "one@:2", "@:2"}, "Include cycle: cycle1.lox -> cycle2.lox -> cycle1.lox."},
+		{"self.lox", []string{"self@:2", "@:2"}, "Include cycle: self.lox -> self.lox."},
+		{"missing.lox", []string{"x@:2", "@:2"}, "Cannot include 'nowhere.lox'"},
+		{"bad.lox", []string{"x@:2", "@:2"}, "Malformed #include directive: expected a quoted file name."},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, test.file)
+		var err string
+		tokens := NewFile(files[test.file], path, func(file string, line, column int, message string) {
+			if err == "" {
+				err = message
+			}
+		}, 0).ScanTokens()
+		var got []string
+		for _, tok := range tokens {
+			got = append(got, fmt.Sprintf("%s@%s:%d", tok.Lexeme, tok.File, tok.Line))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got tokens %q, want %q", test.file, got, test.want)
+		}
+		if !strings.HasPrefix(err, test.err) || (err == "") != (test.err == "") {
+			t.Errorf("%s: got error %q, want %q", test.file, err, test.err)
+		}
+	}
+}
+
+func TestIncludeReader(t *testing.T) {
+	var read []string
+	s := New("//#include \"lib.lox\"\n//#include \"lib.lox\"\nmain", func(file string, line, column int, message string) {
+		t.Errorf("unexpected error: %s", message)
+	}, 0)
+	s.SetIncludeReader(func(site token.Token, path string) ([]byte, error) {
+		read = append(read, fmt.Sprintf("%s at %d:%d", path, site.Line, site.Column))
+		return []byte("lib"), nil
+	})
+	var got []string
+	for _, tok := range s.ScanTokens() {
+		got = append(got, tok.Lexeme)
+	}
+	if want := []string{"lib", "main", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got tokens %q, want %q", got, want)
+	}
+	if want := []string{"lib.lox at 1:1"}; !reflect.DeepEqual(read, want) {
+		t.Errorf("read %q, want %q", read, want)
+	}
+
+	var err string
+	s = New("//#include \"lib.lox\"\nmain", func(file string, line, column int, message string) {
+		err = fmt.Sprintf("%d:%d: %s", line, column, message)
+	}, 0)
+	s.SetIncludeReader(nil)
+	s.ScanTokens()
+	if want := "1:1: Includes are disabled."; err != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,233 @@
+// Package token defines the lexical tokens of Lox.
+package token
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/kriyanshii/interpreter-go/internal/number"
+)
+
+// Type identifies the lexical category of a Token.
+type Type int
+
+const (
+	// Single-character tokens.
+	LeftParen Type = iota
+	RightParen
+	LeftBrace
+	RightBrace
+	Comma
+	Dot
+	Minus
+	Plus
+	Semicolon
+	Slash
+	Star
+	Percent
+	Question
+	Colon
+
+	// One or two character tokens.
+	Bang
+	BangEqual
+	Equal
+	EqualEqual
+	Greater
+	GreaterEqual
+	Less
+	LessEqual
+	MinusEqual
+	PlusEqual
+	SlashEqual
+	StarEqual
+	PercentEqual
+	MinusMinus
+	PlusPlus
+	Arrow
+
+	// Literals.
+	Identifier
+	String
+	// Interpolation is the part of a string literal before an embedded
+	// expression: the tokens of the expression follow it, and then either
+	// another Interpolation or a String with the rest of the literal.
+	Interpolation
+	Number
+
+	// Keywords.
+	And
+	Break
+	Class
+	Continue
+	Else
+	False
+	Fun
+	For
+	If
+	Nil
+	Or
+	Print
+	Return
+	Super
+	This
+	True
+	Var
+	While
+
+	EOF
+)
+
+var tokenTypeNames = [...]string{
+	LeftParen:     "LEFT_PAREN",
+	RightParen:    "RIGHT_PAREN",
+	LeftBrace:     "LEFT_BRACE",
+	RightBrace:    "RIGHT_BRACE",
+	Comma:         "COMMA",
+	Dot:           "DOT",
+	Minus:         "MINUS",
+	Plus:          "PLUS",
+	Semicolon:     "SEMICOLON",
+	Slash:         "SLASH",
+	Star:          "STAR",
+	Percent:       "PERCENT",
+	Question:      "QUESTION",
+	Colon:         "COLON",
+	Bang:          "BANG",
+	BangEqual:     "BANG_EQUAL",
+	Equal:         "EQUAL",
+	EqualEqual:    "EQUAL_EQUAL",
+	Greater:       "GREATER",
+	GreaterEqual:  "GREATER_EQUAL",
+	Less:          "LESS",
+	LessEqual:     "LESS_EQUAL",
+	MinusEqual:    "MINUS_EQUAL",
+	PlusEqual:     "PLUS_EQUAL",
+	SlashEqual:    "SLASH_EQUAL",
+	StarEqual:     "STAR_EQUAL",
+	PercentEqual:  "PERCENT_EQUAL",
+	MinusMinus:    "MINUS_MINUS",
+	PlusPlus:      "PLUS_PLUS",
+	Arrow:         "ARROW",
+	Identifier:    "IDENTIFIER",
+	String:        "STRING",
+	Interpolation: "INTERPOLATION",
+	Number:        "NUMBER",
+	And:           "AND",
+	Break:         "BREAK",
+	Class:         "CLASS",
+	Continue:      "CONTINUE",
+	Else:          "ELSE",
+	False:         "FALSE",
+	Fun:           "FUN",
+	For:           "FOR",
+	If:            "IF",
+	Nil:           "NIL",
+	Or:            "OR",
+	Print:         "PRINT",
+	Return:        "RETURN",
+	Super:         "SUPER",
+	This:          "THIS",
+	True:          "TRUE",
+	Var:           "VAR",
+	While:         "WHILE",
+	EOF:           "EOF",
+}
+
+func (t Type) String() string {
+	if int(t) < len(tokenTypeNames) && tokenTypeNames[t] != "" {
+		return tokenTypeNames[t]
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+var keywords = map[string]Type{
+	"and":      And,
+	"break":    Break,
+	"class":    Class,
+	"continue": Continue,
+	"else":     Else,
+	"false":    False,
+	"for":      For,
+	"fun":      Fun,
+	"if":       If,
+	"nil":      Nil,
+	"or":       Or,
+	"print":    Print,
+	"return":   Return,
+	"super":    Super,
+	"this":     This,
+	"true":     True,
+	"var":      Var,
+	"while":    While,
+}
+
+// keywordSpellings maps each keyword type back to how it is written.
+var keywordSpellings = func() map[Type]string {
+	spellings := make(map[Type]string, len(keywords))
+	for word, t := range keywords {
+		spellings[t] = word
+	}
+	return spellings
+}()
+
+// Keyword returns how the keyword t is written in Lox, such as "while"
+// for While, or "" if t is not a keyword. A token of a keyword type may be
+// spelled otherwise, by a keyword alias.
+func (t Type) Keyword() string {
+	return keywordSpellings[t]
+}
+
+// Lookup maps an identifier to its keyword token type, or Identifier if it
+// is not a keyword. Keywords are all ASCII, so identifiers that start with
+// another letter are never looked up.
+func Lookup(ident string) Type {
+	if ident == "" || ident[0] >= utf8.RuneSelf {
+		return Identifier
+	}
+	if t, ok := keywords[ident]; ok {
+		return t
+	}
+	return Identifier
+}
+
+// Token is a single lexeme produced by the Scanner. File is empty unless a
+// //#line directive named the file the token should be attributed to.
+//
+// Line is the line the token ends on, as in jlox, which only differs from
+// the line it starts on for multi-line strings. Column is the 1-based
+// column, in characters, of the token's first character, and Offset and
+// Length locate its lexeme in the source in bytes.
+type Token struct {
+	Type    Type
+	Lexeme  string
+	Literal any
+	Line    int
+	File    string
+	Column  int
+	Offset  int
+	Length  int
+}
+
+// String renders the token as "TYPE lexeme literal", the format printed by
+// the tokenize command.
+func (t Token) String() string {
+	return fmt.Sprintf("%s %s %s", t.Type, t.Lexeme, formatLiteral(t.Literal))
+}
+
+func formatLiteral(literal any) string {
+	switch v := literal.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return number.FormatLiteral(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ErrorHandler is called with each static error found at a token, whether
+// a syntax error from the parser or a scoping error from the resolver.
+type ErrorHandler func(tok Token, message string)